@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -77,6 +78,29 @@ func (a *App) startup(ctx context.Context) {
 	if err := a.devToolsManager.Initialize(); err != nil {
 		log.Printf("Error initializing DevTools manager: %v", err)
 	}
+
+	// Let the DevTools push events to the frontend
+	a.devToolsManager.SetEventEmitter(func(eventName string, data ...interface{}) {
+		runtime.EventsEmit(ctx, eventName, data...)
+	})
+
+	// Fetch Git repositories in the background to notice new upstream commits
+	a.devToolsManager.ResumeGitAutoFetch()
+
+	// Refresh Git repositories as soon as their files change
+	if err := a.devToolsManager.StartGitWatching(); err != nil {
+		log.Printf("Error watching Git repositories: %v", err)
+	}
+}
+
+// formatError passes Git errors to the frontend as objects so it can react to
+// their code; other errors are sent as plain messages
+func formatError(err error) any {
+	var gitErr *devtools.GitError
+	if errors.As(err, &gitErr) {
+		return gitErr
+	}
+	return err.Error()
 }
 
 // shutdown is called when the app is closing
@@ -87,6 +111,9 @@ func (a *App) shutdown(ctx context.Context) {
 	// Stop the process manager
 	a.processManager.Stop()
 
+	// Stop background DevTools activity
+	a.devToolsManager.Shutdown()
+
 	// Close the Git repository manager
 	if gitManager := a.devToolsManager.GetGitRepoManager(); gitManager != nil {
 		if err := gitManager.Close(); err != nil {
@@ -342,6 +369,208 @@ func (a *App) GetSavedAPIRequests() []devtools.APIRequest {
 	return a.devToolsManager.GetSavedAPIRequests()
 }
 
+// SetAPIAuthSecrets stores the secrets referenced by an API request's auth block
+func (a *App) SetAPIAuthSecrets(ref string, secrets devtools.AuthSecrets) error {
+	return a.devToolsManager.SetAPIAuthSecrets(ref, secrets)
+}
+
+// DeleteAPIAuthSecrets removes stored API auth secrets
+func (a *App) DeleteAPIAuthSecrets(ref string) error {
+	return a.devToolsManager.DeleteAPIAuthSecrets(ref)
+}
+
+// ClearAPITokenCache discards cached OAuth2 access tokens
+func (a *App) ClearAPITokenCache() {
+	a.devToolsManager.ClearAPITokenCache()
+}
+
+// GetAPICookies returns the cookies an environment's jar would send to a URL
+func (a *App) GetAPICookies(environment, url string) ([]devtools.APICookie, error) {
+	return a.devToolsManager.GetAPICookies(environment, url)
+}
+
+// ClearAPICookies discards the cookie jar of an environment
+func (a *App) ClearAPICookies(environment string) {
+	a.devToolsManager.ClearAPICookies(environment)
+}
+
+// SendGraphQLRequest validates and sends a GraphQL operation
+func (a *App) SendGraphQLRequest(req devtools.GraphQLRequest) devtools.APIResponse {
+	return a.devToolsManager.SendGraphQLRequest(req)
+}
+
+// IntrospectGraphQLSchema fetches and caches the schema of a GraphQL endpoint
+func (a *App) IntrospectGraphQLSchema(req devtools.GraphQLRequest, refresh bool) (devtools.GraphQLSchemaInfo, error) {
+	return a.devToolsManager.IntrospectGraphQLSchema(req, refresh)
+}
+
+// ValidateGraphQLQuery checks a GraphQL operation against the endpoint's schema
+func (a *App) ValidateGraphQLQuery(req devtools.GraphQLRequest) ([]string, error) {
+	return a.devToolsManager.ValidateGraphQLQuery(req)
+}
+
+// GetGraphQLOperations returns the queries, mutations and subscriptions of a cached schema
+func (a *App) GetGraphQLOperations(url string) ([]devtools.GraphQLOperation, error) {
+	return a.devToolsManager.GetGraphQLOperations(url)
+}
+
+// OpenAPIStream opens a WebSocket or Server-Sent Events session
+func (a *App) OpenAPIStream(config devtools.StreamConfig) (devtools.StreamSession, error) {
+	return a.devToolsManager.OpenAPIStream(config)
+}
+
+// SendAPIStreamMessage sends a text or base64-encoded binary frame on a WebSocket session
+func (a *App) SendAPIStreamMessage(sessionID, data string, binary bool) error {
+	return a.devToolsManager.SendAPIStreamMessage(sessionID, data, binary)
+}
+
+// CloseAPIStream closes a streaming session with a close code and reason
+func (a *App) CloseAPIStream(sessionID string, code int, reason string) (devtools.StreamSession, error) {
+	return a.devToolsManager.CloseAPIStream(sessionID, code, reason)
+}
+
+// RemoveAPIStream closes a streaming session and discards its transcript
+func (a *App) RemoveAPIStream(sessionID string) error {
+	return a.devToolsManager.RemoveAPIStream(sessionID)
+}
+
+// GetAPIStreams returns all streaming sessions
+func (a *App) GetAPIStreams() []devtools.StreamSession {
+	return a.devToolsManager.GetAPIStreams()
+}
+
+// GetAPIStreamTranscript returns the messages of a streaming session
+func (a *App) GetAPIStreamTranscript(sessionID string) ([]devtools.StreamMessage, error) {
+	return a.devToolsManager.GetAPIStreamTranscript(sessionID)
+}
+
+// SaveAPIStreamTranscript writes a streaming session transcript to a JSON file
+func (a *App) SaveAPIStreamTranscript(sessionID, path string) error {
+	return a.devToolsManager.SaveAPIStreamTranscript(sessionID, path)
+}
+
+// ListGRPCServices lists the services and methods of a gRPC target
+func (a *App) ListGRPCServices(target devtools.GRPCTarget) ([]devtools.GRPCService, error) {
+	return a.devToolsManager.ListGRPCServices(target)
+}
+
+// InvokeGRPC calls a unary or server-streaming gRPC method
+func (a *App) InvokeGRPC(req devtools.GRPCRequest) devtools.GRPCResponse {
+	return a.devToolsManager.InvokeGRPC(req)
+}
+
+// StartMockServer starts the mock server on a local port
+func (a *App) StartMockServer(port int) (devtools.MockServerInfo, error) {
+	return a.devToolsManager.StartMockServer(port)
+}
+
+// StopMockServer stops the mock server
+func (a *App) StopMockServer() (devtools.MockServerInfo, error) {
+	return a.devToolsManager.StopMockServer()
+}
+
+// GetMockServerInfo returns the state of the mock server
+func (a *App) GetMockServerInfo() devtools.MockServerInfo {
+	return a.devToolsManager.GetMockServerInfo()
+}
+
+// GetMockRoutes returns all mock routes
+func (a *App) GetMockRoutes() []devtools.MockRoute {
+	return a.devToolsManager.GetMockRoutes()
+}
+
+// SaveMockRoute adds a mock route or replaces the one with the same ID
+func (a *App) SaveMockRoute(route devtools.MockRoute) (devtools.MockRoute, error) {
+	return a.devToolsManager.SaveMockRoute(route)
+}
+
+// RemoveMockRoute removes a mock route
+func (a *App) RemoveMockRoute(routeID string) error {
+	return a.devToolsManager.RemoveMockRoute(routeID)
+}
+
+// CreateMockRouteFromResponse creates a mock route that replays an API response
+func (a *App) CreateMockRouteFromResponse(req devtools.APIRequest, resp devtools.APIResponse) (devtools.MockRoute, error) {
+	return a.devToolsManager.CreateMockRouteFromResponse(req, resp)
+}
+
+// GetMockRequestLog returns the requests received by the mock server
+func (a *App) GetMockRequestLog() []devtools.MockRequestLog {
+	return a.devToolsManager.GetMockRequestLog()
+}
+
+// ClearMockRequestLog discards the mock server request log
+func (a *App) ClearMockRequestLog() {
+	a.devToolsManager.ClearMockRequestLog()
+}
+
+// StartAPIBenchmark starts a load test of an API request
+func (a *App) StartAPIBenchmark(config devtools.BenchmarkConfig) (devtools.BenchmarkResult, error) {
+	return a.devToolsManager.StartAPIBenchmark(config)
+}
+
+// CancelAPIBenchmark stops a running load test
+func (a *App) CancelAPIBenchmark(benchmarkID string) (devtools.BenchmarkResult, error) {
+	return a.devToolsManager.CancelAPIBenchmark(benchmarkID)
+}
+
+// GetAPIBenchmark returns the progress or result of a load test
+func (a *App) GetAPIBenchmark(benchmarkID string) (devtools.BenchmarkResult, error) {
+	return a.devToolsManager.GetAPIBenchmark(benchmarkID)
+}
+
+// StartRecordingProxy starts the recording proxy
+func (a *App) StartRecordingProxy(config devtools.ProxyConfig) (devtools.ProxyInfo, error) {
+	return a.devToolsManager.StartRecordingProxy(config)
+}
+
+// StopRecordingProxy stops the recording proxy
+func (a *App) StopRecordingProxy() (devtools.ProxyInfo, error) {
+	return a.devToolsManager.StopRecordingProxy()
+}
+
+// GetRecordingProxyInfo returns the state of the recording proxy
+func (a *App) GetRecordingProxyInfo() devtools.ProxyInfo {
+	return a.devToolsManager.GetRecordingProxyInfo()
+}
+
+// GetProxyRecords returns the exchanges captured by the recording proxy
+func (a *App) GetProxyRecords() []devtools.ProxyRecord {
+	return a.devToolsManager.GetProxyRecords()
+}
+
+// DeleteProxyRecord removes a captured exchange
+func (a *App) DeleteProxyRecord(recordID string) error {
+	return a.devToolsManager.DeleteProxyRecord(recordID)
+}
+
+// ClearProxyRecords discards all captured exchanges
+func (a *App) ClearProxyRecords() {
+	a.devToolsManager.ClearProxyRecords()
+}
+
+// ReplayProxyRecord sends a captured request again through the API tester,
+// including the credential headers withheld from the record
+func (a *App) ReplayProxyRecord(recordID string) (devtools.APIResponse, error) {
+	return a.devToolsManager.ReplayProxyRecord(recordID)
+}
+
+// SaveProxyRecordCredentials stores the credentials captured with an exchange
+// in the secret store and returns its request for saving
+func (a *App) SaveProxyRecordCredentials(recordID string) (devtools.APIRequest, error) {
+	return a.devToolsManager.SaveProxyRecordCredentials(recordID)
+}
+
+// CreateMockRouteFromRecord creates a mock route that replays a captured response
+func (a *App) CreateMockRouteFromRecord(recordID string) (devtools.MockRoute, error) {
+	return a.devToolsManager.CreateMockRouteFromRecord(recordID)
+}
+
+// GenerateRequestCode renders an API request as curl, go, fetch or python code
+func (a *App) GenerateRequestCode(req devtools.APIRequest, language string) (string, error) {
+	return a.devToolsManager.GenerateRequestCode(req, devtools.CodeLanguage(language))
+}
+
 // GetAllGitRepos returns all registered Git repositories
 func (a *App) GetAllGitRepos() []devtools.GitRepoInfo {
 	return a.devToolsManager.GetAllGitRepos()
@@ -368,10 +597,250 @@ func (a *App) RemoveGitRepo(repoID string) error {
 }
 
 // GetGitRepoChanges returns the changes in a Git repository
-func (a *App) GetGitRepoChanges(repoID string) ([]string, error) {
+func (a *App) GetGitRepoChanges(repoID string) ([]devtools.GitFileChange, error) {
 	return a.devToolsManager.GetGitRepoChanges(repoID)
 }
 
+// GetGitCommitLog returns a page of the commit history of a Git repository
+func (a *App) GetGitCommitLog(repoID string, options devtools.GitLogOptions) (devtools.GitLogPage, error) {
+	return a.devToolsManager.GetGitCommitLog(repoID, options)
+}
+
+// GetGitCommitDetail returns a commit with the files it changed
+func (a *App) GetGitCommitDetail(repoID, hash string) (devtools.GitCommitDetail, error) {
+	return a.devToolsManager.GetGitCommitDetail(repoID, hash)
+}
+
+// ListGitBranches returns the local and remote branches of a Git repository
+func (a *App) ListGitBranches(repoID string) ([]devtools.GitBranch, error) {
+	return a.devToolsManager.ListGitBranches(repoID)
+}
+
+// CreateGitBranch creates a branch from a ref and optionally switches to it
+func (a *App) CreateGitBranch(repoID, name, startPoint string, checkout bool) (devtools.GitBranch, error) {
+	return a.devToolsManager.CreateGitBranch(repoID, name, startPoint, checkout)
+}
+
+// CheckoutGitBranch switches branches; dirtyPolicy is "refuse" or "stash"
+func (a *App) CheckoutGitBranch(repoID, name, dirtyPolicy string) (devtools.GitCheckoutResult, error) {
+	return a.devToolsManager.CheckoutGitBranch(repoID, name, dirtyPolicy)
+}
+
+// DeleteGitBranch deletes a local branch, checking it is merged unless force is set
+func (a *App) DeleteGitBranch(repoID, name string, force bool) error {
+	return a.devToolsManager.DeleteGitBranch(repoID, name, force)
+}
+
+// SetGitBranchUpstream sets or unsets the upstream of a local branch
+func (a *App) SetGitBranchUpstream(repoID, name, upstream string) (devtools.GitBranch, error) {
+	return a.devToolsManager.SetGitBranchUpstream(repoID, name, upstream)
+}
+
+// GetGitFileDiff returns the staged or unstaged diff of a file in a Git repository
+func (a *App) GetGitFileDiff(repoID, path string, staged bool) (devtools.GitFileDiff, error) {
+	return a.devToolsManager.GetGitFileDiff(repoID, path, staged)
+}
+
+// StageGitFiles stages files in a Git repository
+func (a *App) StageGitFiles(repoID string, paths []string) error {
+	return a.devToolsManager.StageGitFiles(repoID, paths)
+}
+
+// UnstageGitFiles unstages files in a Git repository
+func (a *App) UnstageGitFiles(repoID string, paths []string) error {
+	return a.devToolsManager.UnstageGitFiles(repoID, paths)
+}
+
+// StageGitHunk stages one hunk of a file's unstaged changes
+func (a *App) StageGitHunk(repoID, path, hunkHeader string) error {
+	return a.devToolsManager.StageGitHunk(repoID, path, hunkHeader)
+}
+
+// UnstageGitHunk unstages one hunk of a file's staged changes
+func (a *App) UnstageGitHunk(repoID, path, hunkHeader string) error {
+	return a.devToolsManager.UnstageGitHunk(repoID, path, hunkHeader)
+}
+
+// PrepareGitDiscard lists the changes a discard would lose and returns a confirmation token
+func (a *App) PrepareGitDiscard(repoID string, paths []string) (devtools.GitDiscardPlan, error) {
+	return a.devToolsManager.PrepareGitDiscard(repoID, paths)
+}
+
+// DiscardGitChanges discards the working tree changes confirmed by a token
+func (a *App) DiscardGitChanges(repoID, token string) error {
+	return a.devToolsManager.DiscardGitChanges(repoID, token)
+}
+
+// CommitGitChanges commits the staged changes of a Git repository
+func (a *App) CommitGitChanges(repoID string, options devtools.GitCommitOptions) (devtools.GitCommit, error) {
+	return a.devToolsManager.CommitGitChanges(repoID, options)
+}
+
+// FetchGitRepo fetches all remotes of a Git repository and returns the branches with new upstream commits
+func (a *App) FetchGitRepo(repoID string) ([]devtools.GitRemoteChange, error) {
+	return a.devToolsManager.FetchGitRepo(repoID)
+}
+
+// SetGitRepoAutoFetch enables or disables background fetching for a Git repository
+func (a *App) SetGitRepoAutoFetch(repoID string, enabled bool) (devtools.GitRepoInfo, error) {
+	return a.devToolsManager.SetGitRepoAutoFetch(repoID, enabled)
+}
+
+// StartGitAutoFetch starts fetching all Git repositories in the background
+func (a *App) StartGitAutoFetch(config devtools.GitFetchConfig) devtools.GitFetchStatus {
+	return a.devToolsManager.StartGitAutoFetch(config)
+}
+
+// StopGitAutoFetch stops fetching Git repositories in the background until it is started again
+func (a *App) StopGitAutoFetch() {
+	a.devToolsManager.StopGitAutoFetch()
+}
+
+// GetGitAutoFetchStatus returns the state of the background fetch scheduler
+func (a *App) GetGitAutoFetchStatus() devtools.GitFetchStatus {
+	return a.devToolsManager.GetGitAutoFetchStatus()
+}
+
+// StartGitWatching refreshes Git repositories when their files change
+func (a *App) StartGitWatching() error {
+	return a.devToolsManager.StartGitWatching()
+}
+
+// StopGitWatching stops watching Git repositories for changes
+func (a *App) StopGitWatching() {
+	a.devToolsManager.StopGitWatching()
+}
+
+// ScanForGitRepos finds Git repositories, worktrees and submodules below a directory
+func (a *App) ScanForGitRepos(root string, maxDepth int) ([]devtools.GitRepoCandidate, error) {
+	return a.devToolsManager.ScanForGitRepos(root, maxDepth)
+}
+
+// AddScannedGitRepos registers repositories found by ScanForGitRepos, skipping duplicates
+func (a *App) AddScannedGitRepos(candidates []devtools.GitRepoCandidate) []devtools.GitRepoImportResult {
+	return a.devToolsManager.AddScannedGitRepos(candidates)
+}
+
+// BatchFetchGitRepos fetches all remotes of several Git repositories
+func (a *App) BatchFetchGitRepos(repoIDs []string) []devtools.GitBatchResult {
+	return a.devToolsManager.BatchFetchGitRepos(repoIDs)
+}
+
+// BatchPullGitRepos fast-forwards several Git repositories to their upstream
+func (a *App) BatchPullGitRepos(repoIDs []string) []devtools.GitBatchResult {
+	return a.devToolsManager.BatchPullGitRepos(repoIDs)
+}
+
+// BatchCheckoutGitBranch switches several Git repositories to a branch where it exists
+func (a *App) BatchCheckoutGitBranch(repoIDs []string, branch string) []devtools.GitBatchResult {
+	return a.devToolsManager.BatchCheckoutGitBranch(repoIDs, branch)
+}
+
+// BatchRunGitRepoCommand runs a shell command in several Git repositories
+func (a *App) BatchRunGitRepoCommand(repoIDs []string, command string) []devtools.GitBatchResult {
+	return a.devToolsManager.BatchRunGitRepoCommand(repoIDs, command)
+}
+
+// SetGitRepoTags replaces the tags of a Git repository
+func (a *App) SetGitRepoTags(repoID string, tags []string) (devtools.GitRepoInfo, error) {
+	return a.devToolsManager.SetGitRepoTags(repoID, tags)
+}
+
+// GetGitRepoTags returns every tag used by Git repositories
+func (a *App) GetGitRepoTags() []string {
+	return a.devToolsManager.GetGitRepoTags()
+}
+
+// GetGitRepoGroups returns all Git repository groups
+func (a *App) GetGitRepoGroups() []devtools.GitRepoGroup {
+	return a.devToolsManager.GetGitRepoGroups()
+}
+
+// CreateGitRepoGroup creates an empty Git repository group
+func (a *App) CreateGitRepoGroup(name, description string) (devtools.GitRepoGroup, error) {
+	return a.devToolsManager.CreateGitRepoGroup(name, description)
+}
+
+// DeleteGitRepoGroup deletes a Git repository group
+func (a *App) DeleteGitRepoGroup(groupID string) error {
+	return a.devToolsManager.DeleteGitRepoGroup(groupID)
+}
+
+// AddGitReposToGroup adds Git repositories to a group
+func (a *App) AddGitReposToGroup(groupID string, repoIDs []string) (devtools.GitRepoGroup, error) {
+	return a.devToolsManager.AddGitReposToGroup(groupID, repoIDs)
+}
+
+// RemoveGitReposFromGroup removes Git repositories from a group
+func (a *App) RemoveGitReposFromGroup(groupID string, repoIDs []string) (devtools.GitRepoGroup, error) {
+	return a.devToolsManager.RemoveGitReposFromGroup(groupID, repoIDs)
+}
+
+// QueryGitRepos returns the Git repositories matching a query
+func (a *App) QueryGitRepos(query devtools.GitRepoQuery) ([]devtools.GitRepoInfo, error) {
+	return a.devToolsManager.QueryGitRepos(query)
+}
+
+// GetGitBlame returns the commit that last changed each line of a file
+func (a *App) GetGitBlame(repoID, path, rev string) (devtools.GitBlame, error) {
+	return a.devToolsManager.GetGitBlame(repoID, path, rev)
+}
+
+// GetGitFileHistory returns the commits that changed a file, following renames
+func (a *App) GetGitFileHistory(repoID, path string) ([]devtools.GitFileRevision, error) {
+	return a.devToolsManager.GetGitFileHistory(repoID, path)
+}
+
+// ListGitStashes returns the stash entries of a Git repository
+func (a *App) ListGitStashes(repoID string) ([]devtools.GitStash, error) {
+	return a.devToolsManager.ListGitStashes(repoID)
+}
+
+// ShowGitStash returns the files and diffs of a stash entry
+func (a *App) ShowGitStash(repoID, hash string) (devtools.GitStashDetail, error) {
+	return a.devToolsManager.ShowGitStash(repoID, hash)
+}
+
+// ApplyGitStash applies a stash entry and keeps it
+func (a *App) ApplyGitStash(repoID, hash string) error {
+	return a.devToolsManager.ApplyGitStash(repoID, hash)
+}
+
+// PopGitStash applies a stash entry and removes it
+func (a *App) PopGitStash(repoID, hash string) error {
+	return a.devToolsManager.PopGitStash(repoID, hash)
+}
+
+// DropGitStash removes a stash entry
+func (a *App) DropGitStash(repoID, hash string) error {
+	return a.devToolsManager.DropGitStash(repoID, hash)
+}
+
+// CreateGitStash stashes the local changes of a Git repository
+func (a *App) CreateGitStash(repoID, message string, includeUntracked bool) (devtools.GitStash, error) {
+	return a.devToolsManager.CreateGitStash(repoID, message, includeUntracked)
+}
+
+// ListGitWorktrees returns the worktrees of a Git repository
+func (a *App) ListGitWorktrees(repoID string) ([]devtools.GitWorktree, error) {
+	return a.devToolsManager.ListGitWorktrees(repoID)
+}
+
+// CreateGitWorktree checks out a branch of a Git repository in a new worktree
+func (a *App) CreateGitWorktree(repoID string, options devtools.GitWorktreeOptions) (devtools.GitWorktree, error) {
+	return a.devToolsManager.CreateGitWorktree(repoID, options)
+}
+
+// RemoveGitWorktree removes a linked worktree of a Git repository
+func (a *App) RemoveGitWorktree(repoID, path string, force bool) error {
+	return a.devToolsManager.RemoveGitWorktree(repoID, path, force)
+}
+
+// PruneGitWorktrees deletes the records of worktrees whose directory is gone
+func (a *App) PruneGitWorktrees(repoID string) ([]devtools.GitWorktree, error) {
+	return a.devToolsManager.PruneGitWorktrees(repoID)
+}
+
 // OpenInVSCode opens a directory in Visual Studio Code
 func (a *App) OpenInVSCode(path string) error {
 	// Expand path if it contains ~
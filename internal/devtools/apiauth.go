@@ -0,0 +1,404 @@
+package devtools
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthType represents the authentication scheme applied to an API request
+type AuthType string
+
+const (
+	AuthNone   AuthType = "none"
+	AuthBasic  AuthType = "basic"
+	AuthBearer AuthType = "bearer"
+	AuthAPIKey AuthType = "apikey"
+	AuthOAuth2 AuthType = "oauth2"
+)
+
+// OAuth2 grant types supported by the API tester
+const (
+	OAuth2ClientCredentials = "client_credentials"
+	OAuth2Password          = "password"
+)
+
+// APIAuth describes how an API request is authenticated.
+// Secret values (passwords, tokens, keys) are not part of the request
+// definition; they are looked up in the secret store by SecretRef.
+type APIAuth struct {
+	Type       AuthType `json:"type"`
+	SecretRef  string   `json:"secretRef,omitempty"`
+	Username   string   `json:"username,omitempty"`
+	APIKeyName string   `json:"apiKeyName,omitempty"`
+	APIKeyIn   string   `json:"apiKeyIn,omitempty"` // "header" or "query"
+	GrantType  string   `json:"grantType,omitempty"`
+	TokenURL   string   `json:"tokenUrl,omitempty"`
+	ClientID   string   `json:"clientId,omitempty"`
+	Scopes     []string `json:"scopes,omitempty"`
+}
+
+// AuthSecrets holds the secret values referenced by an APIAuth
+type AuthSecrets struct {
+	Password     string `json:"password,omitempty"`
+	Token        string `json:"token,omitempty"`
+	APIKey       string `json:"apiKey,omitempty"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+}
+
+// SecretStore persists authentication secrets separately from request definitions
+type SecretStore struct {
+	secrets map[string]AuthSecrets
+	mutex   sync.Mutex
+	path    string
+}
+
+var (
+	secretStore     *SecretStore
+	secretStoreOnce sync.Once
+)
+
+// GetSecretStore returns the singleton instance of SecretStore
+func GetSecretStore() *SecretStore {
+	secretStoreOnce.Do(func() {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			fmt.Printf("Error getting user home directory: %v\n", err)
+			home = "."
+		}
+
+		secretStore = &SecretStore{
+			secrets: make(map[string]AuthSecrets),
+			path:    filepath.Join(home, ".devex", "secrets.json"),
+		}
+		secretStore.load()
+	})
+	return secretStore
+}
+
+// load reads the secrets file if it exists
+func (ss *SecretStore) load() {
+	data, err := os.ReadFile(ss.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error reading secrets file: %v\n", err)
+		}
+		return
+	}
+
+	if err := json.Unmarshal(data, &ss.secrets); err != nil {
+		fmt.Printf("Error parsing secrets file: %v\n", err)
+	}
+}
+
+// save writes the secrets file readable only by the current user
+func (ss *SecretStore) save() error {
+	if err := os.MkdirAll(filepath.Dir(ss.path), 0755); err != nil {
+		return fmt.Errorf("error creating secrets directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(ss.secrets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding secrets: %v", err)
+	}
+
+	if err := os.WriteFile(ss.path, data, 0600); err != nil {
+		return fmt.Errorf("error writing secrets file: %v", err)
+	}
+	return nil
+}
+
+// Get returns the secrets stored under ref
+func (ss *SecretStore) Get(ref string) (AuthSecrets, bool) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	secrets, exists := ss.secrets[ref]
+	return secrets, exists
+}
+
+// Set stores secrets under ref
+func (ss *SecretStore) Set(ref string, secrets AuthSecrets) error {
+	if ref == "" {
+		return fmt.Errorf("secret reference is required")
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	ss.secrets[ref] = secrets
+	return ss.save()
+}
+
+// Delete removes the secrets stored under ref
+func (ss *SecretStore) Delete(ref string) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	if _, exists := ss.secrets[ref]; !exists {
+		return fmt.Errorf("secret %s not found", ref)
+	}
+
+	delete(ss.secrets, ref)
+	return ss.save()
+}
+
+// oauth2Token is a cached OAuth2 access token
+type oauth2Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	Expiry       time.Time
+}
+
+// valid reports whether the token can still be used, allowing a small margin
+// so that a token doesn't expire while the request is in flight
+func (t *oauth2Token) valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(10*time.Second).Before(t.Expiry)
+}
+
+// tokenResponse is the JSON body returned by an OAuth2 token endpoint
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// applyAuth adds the credentials described by auth to the HTTP request
+func (at *APITester) applyAuth(httpReq *http.Request, auth *APIAuth) error {
+	if auth == nil || auth.Type == "" || auth.Type == AuthNone {
+		return nil
+	}
+
+	// Look up the secrets for this request
+	var secrets AuthSecrets
+	if auth.SecretRef != "" {
+		stored, exists := at.secrets.Get(auth.SecretRef)
+		if !exists {
+			return fmt.Errorf("secret %s not found", auth.SecretRef)
+		}
+		secrets = stored
+	}
+
+	switch auth.Type {
+	case AuthBasic:
+		httpReq.SetBasicAuth(auth.Username, secrets.Password)
+
+	case AuthBearer:
+		if secrets.Token == "" {
+			return fmt.Errorf("bearer token is not set")
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+secrets.Token)
+
+	case AuthAPIKey:
+		if auth.APIKeyName == "" {
+			return fmt.Errorf("API key name is not set")
+		}
+		if auth.APIKeyIn == "query" {
+			query := httpReq.URL.Query()
+			query.Set(auth.APIKeyName, secrets.APIKey)
+			httpReq.URL.RawQuery = query.Encode()
+		} else {
+			httpReq.Header.Set(auth.APIKeyName, secrets.APIKey)
+		}
+
+	case AuthOAuth2:
+		token, err := at.oauth2Token(auth, secrets)
+		if err != nil {
+			return err
+		}
+		tokenType := token.TokenType
+		if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+			tokenType = "Bearer"
+		}
+		httpReq.Header.Set("Authorization", tokenType+" "+token.AccessToken)
+
+	default:
+		return fmt.Errorf("unsupported auth type: %s", auth.Type)
+	}
+
+	return nil
+}
+
+// oauth2CacheKey identifies a cached token by everything that affects what the
+// token endpoint issues. The secrets are hashed so that a changed client secret
+// or password doesn't reuse a token issued for the old one.
+func oauth2CacheKey(auth *APIAuth, secrets AuthSecrets) string {
+	scopes := append([]string(nil), auth.Scopes...)
+	sort.Strings(scopes)
+	secretHash := sha256.Sum256([]byte(secrets.ClientSecret + "\x00" + secrets.Password))
+	return strings.Join([]string{
+		auth.TokenURL,
+		auth.GrantType,
+		auth.ClientID,
+		auth.Username,
+		strings.Join(scopes, " "),
+		hex.EncodeToString(secretHash[:]),
+	}, "\x00")
+}
+
+// oauth2Token returns a valid access token for auth, fetching or refreshing it as needed
+func (at *APITester) oauth2Token(auth *APIAuth, secrets AuthSecrets) (*oauth2Token, error) {
+	if auth.TokenURL == "" {
+		return nil, fmt.Errorf("OAuth2 token URL is not set")
+	}
+
+	key := oauth2CacheKey(auth, secrets)
+	if cached := at.cachedToken(key); cached.valid() {
+		return cached, nil
+	}
+
+	// Requests needing the same token share a single round trip to the token
+	// endpoint; the lock is only held for the cache itself
+	result, err, _ := at.tokenFetches.Do(key, func() (interface{}, error) {
+		cached := at.cachedToken(key)
+		if cached.valid() {
+			return cached, nil
+		}
+
+		token, err := at.fetchOAuth2Token(auth, secrets, cached)
+
+		at.tokenMutex.Lock()
+		defer at.tokenMutex.Unlock()
+		if err != nil {
+			delete(at.tokens, key)
+			return nil, err
+		}
+		at.tokens[key] = token
+		return token, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*oauth2Token), nil
+}
+
+// cachedToken returns the cached token for key, which may be nil or expired
+func (at *APITester) cachedToken(key string) *oauth2Token {
+	at.tokenMutex.Lock()
+	defer at.tokenMutex.Unlock()
+	return at.tokens[key]
+}
+
+// fetchOAuth2Token requests a new token, trying the refresh token of the
+// cached one first and falling back to a new grant if it is rejected
+func (at *APITester) fetchOAuth2Token(auth *APIAuth, secrets AuthSecrets, cached *oauth2Token) (*oauth2Token, error) {
+	if cached != nil && cached.RefreshToken != "" {
+		form := url.Values{}
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", cached.RefreshToken)
+		if token, err := at.requestToken(auth, secrets, form); err == nil {
+			if token.RefreshToken == "" {
+				token.RefreshToken = cached.RefreshToken
+			}
+			return token, nil
+		}
+	}
+
+	form := url.Values{}
+	switch auth.GrantType {
+	case "", OAuth2ClientCredentials:
+		form.Set("grant_type", OAuth2ClientCredentials)
+	case OAuth2Password:
+		form.Set("grant_type", OAuth2Password)
+		form.Set("username", auth.Username)
+		form.Set("password", secrets.Password)
+	default:
+		return nil, fmt.Errorf("unsupported OAuth2 grant type: %s", auth.GrantType)
+	}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+
+	return at.requestToken(auth, secrets, form)
+}
+
+// requestToken posts a grant to the token endpoint and parses the response
+func (at *APITester) requestToken(auth *APIAuth, secrets AuthSecrets, form url.Values) (*oauth2Token, error) {
+	httpReq, err := http.NewRequest(http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating token request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+
+	// Authenticate the client with HTTP Basic as recommended by RFC 6749
+	if auth.ClientID != "" {
+		httpReq.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString(
+			[]byte(url.QueryEscape(auth.ClientID)+":"+url.QueryEscape(secrets.ClientSecret))))
+	}
+
+	issuedAt := time.Now()
+	resp, err := at.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting OAuth2 token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading OAuth2 token response: %v", err)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		// Some providers answer with form encoding instead of JSON
+		values, parseErr := url.ParseQuery(string(body))
+		if parseErr != nil {
+			return nil, fmt.Errorf("error parsing OAuth2 token response: %v", err)
+		}
+		tr.AccessToken = values.Get("access_token")
+		tr.TokenType = values.Get("token_type")
+		tr.RefreshToken = values.Get("refresh_token")
+		tr.Error = values.Get("error")
+		tr.ErrorDescription = values.Get("error_description")
+		fmt.Sscan(values.Get("expires_in"), &tr.ExpiresIn)
+	}
+
+	if tr.Error != "" {
+		if tr.ErrorDescription != "" {
+			return nil, fmt.Errorf("OAuth2 token error: %s: %s", tr.Error, tr.ErrorDescription)
+		}
+		return nil, fmt.Errorf("OAuth2 token error: %s", tr.Error)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("OAuth2 token endpoint returned %s", resp.Status)
+	}
+	if tr.AccessToken == "" {
+		return nil, fmt.Errorf("OAuth2 token response did not include an access token")
+	}
+
+	token := &oauth2Token{
+		AccessToken:  tr.AccessToken,
+		TokenType:    tr.TokenType,
+		RefreshToken: tr.RefreshToken,
+	}
+	if tr.ExpiresIn > 0 {
+		token.Expiry = issuedAt.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// ClearTokenCache discards all cached OAuth2 tokens
+func (at *APITester) ClearTokenCache() {
+	at.tokenMutex.Lock()
+	defer at.tokenMutex.Unlock()
+	at.tokens = make(map[string]*oauth2Token)
+}
@@ -0,0 +1,556 @@
+package devtools
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// BenchmarkEvent is the Wails event emitted with benchmark progress
+const BenchmarkEvent = "apitester:benchmark"
+
+// benchmarkProgressInterval is how often progress is emitted while a benchmark runs
+const benchmarkProgressInterval = 500 * time.Millisecond
+
+// benchmarkBuckets are the upper bounds (in milliseconds) of the latency histogram
+var benchmarkBuckets = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000, 30000}
+
+// Percentiles are estimated while a benchmark runs from a histogram whose
+// buckets grow by benchmarkEstimateGrowth, starting at a microsecond. The last
+// bucket also holds anything slower than about 400 seconds.
+const (
+	benchmarkEstimateGrowth  = 1.02
+	benchmarkEstimateBuckets = 1000
+)
+
+// maxFinishedBenchmarks is how many finished benchmarks are kept for GetBenchmark
+const maxFinishedBenchmarks = 20
+
+// BenchmarkConfig describes a load test of a single request. The run stops after
+// TotalRequests or DurationSeconds, whichever is reached first.
+type BenchmarkConfig struct {
+	Request         APIRequest `json:"request"`
+	Concurrency     int        `json:"concurrency"`
+	TotalRequests   int        `json:"totalRequests"`
+	DurationSeconds int        `json:"durationSeconds"`
+	RateLimit       float64    `json:"rateLimit"` // requests per second, 0 for unlimited
+}
+
+// BenchmarkLatency summarises request latencies in milliseconds
+type BenchmarkLatency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// BenchmarkBucket is one bar of the latency histogram. The last bucket has no
+// upper bound (UpperBoundMs is 0).
+type BenchmarkBucket struct {
+	UpperBoundMs float64 `json:"upperBoundMs"`
+	Count        int     `json:"count"`
+}
+
+// BenchmarkResult reports the progress or outcome of a benchmark run
+type BenchmarkResult struct {
+	ID            string            `json:"id"`
+	Status        string            `json:"status"` // "running", "completed" or "cancelled"
+	StartedAt     time.Time         `json:"startedAt"`
+	FinishedAt    time.Time         `json:"finishedAt,omitempty"`
+	Elapsed       float64           `json:"elapsed"` // in milliseconds
+	Completed     int               `json:"completed"`
+	Succeeded     int               `json:"succeeded"`
+	Failed        int               `json:"failed"`
+	Throughput    float64           `json:"throughput"` // requests per second
+	BytesReceived int64             `json:"bytesReceived"`
+	StatusCodes   map[string]int    `json:"statusCodes"`
+	Errors        map[string]int    `json:"errors"`
+	Latency       BenchmarkLatency  `json:"latency"`
+	Histogram     []BenchmarkBucket `json:"histogram"`
+}
+
+// benchmarkRun is the live state of a benchmark
+type benchmarkRun struct {
+	result     BenchmarkResult
+	latencies  []float64 // released once the run is summarised
+	stats      latencyStats
+	summarised bool // result.Latency and result.Histogram are final
+	cancel     context.CancelFunc
+	done       chan struct{}
+	mutex      sync.Mutex
+}
+
+// latencyStats is a running summary of latencies in milliseconds, cheap to
+// read while a benchmark is still recording
+type latencyStats struct {
+	count     int
+	total     float64
+	min       float64
+	max       float64
+	histogram []int // counts per benchmarkBuckets entry, plus one for slower latencies
+	estimate  [benchmarkEstimateBuckets]int
+}
+
+// add records a latency
+func (ls *latencyStats) add(latency float64) {
+	if ls.count == 0 || latency < ls.min {
+		ls.min = latency
+	}
+	if latency > ls.max {
+		ls.max = latency
+	}
+	if ls.histogram == nil {
+		ls.histogram = make([]int, len(benchmarkBuckets)+1)
+	}
+	ls.count++
+	ls.total += latency
+	ls.histogram[sort.SearchFloat64s(benchmarkBuckets, latency)]++
+
+	bucket := int(math.Log1p(latency*1000) / math.Log(benchmarkEstimateGrowth))
+	if bucket >= benchmarkEstimateBuckets {
+		bucket = benchmarkEstimateBuckets - 1
+	}
+	ls.estimate[bucket]++
+}
+
+// summary returns the latency histogram and percentiles estimated to within
+// the width of an estimate bucket
+func (ls *latencyStats) summary() (BenchmarkLatency, []BenchmarkBucket) {
+	histogram := make([]BenchmarkBucket, len(benchmarkBuckets)+1)
+	for i := range histogram {
+		if i < len(benchmarkBuckets) {
+			histogram[i].UpperBoundMs = benchmarkBuckets[i]
+		}
+	}
+	if ls.count == 0 {
+		return BenchmarkLatency{}, histogram
+	}
+	for i, count := range ls.histogram {
+		histogram[i].Count = count
+	}
+
+	percentile := func(p float64) float64 {
+		rank := int(p*float64(ls.count) + 0.5)
+		if rank < 1 {
+			rank = 1
+		}
+		seen := 0
+		for bucket, count := range ls.estimate {
+			if seen += count; seen >= rank {
+				// The middle of the bucket, converted back to milliseconds
+				lower := math.Pow(benchmarkEstimateGrowth, float64(bucket)) - 1
+				upper := math.Pow(benchmarkEstimateGrowth, float64(bucket+1)) - 1
+				return math.Min(math.Max((lower+upper)/2000, ls.min), ls.max)
+			}
+		}
+		return ls.max
+	}
+
+	return BenchmarkLatency{
+		Min:  ls.min,
+		Mean: ls.total / float64(ls.count),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P99:  percentile(0.99),
+		Max:  ls.max,
+	}, histogram
+}
+
+// record adds the outcome of one request to the run
+func (run *benchmarkRun) record(latency time.Duration, statusCode int, bytes int64, err error) {
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+
+	run.result.Completed++
+	run.result.BytesReceived += bytes
+	ms := float64(latency.Microseconds()) / 1000
+	run.latencies = append(run.latencies, ms)
+	run.stats.add(ms)
+
+	if err != nil {
+		run.result.Failed++
+		run.result.Errors[benchmarkErrorType(err)]++
+		return
+	}
+
+	run.result.StatusCodes[strconv.Itoa(statusCode)]++
+	if statusCode >= 400 {
+		run.result.Failed++
+		run.result.Errors[fmt.Sprintf("http %dxx", statusCode/100)]++
+	} else {
+		run.result.Succeeded++
+	}
+}
+
+// snapshot computes the current statistics of the run
+func (run *benchmarkRun) snapshot() BenchmarkResult {
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+
+	result := run.result
+	result.StatusCodes = make(map[string]int, len(run.result.StatusCodes))
+	for code, count := range run.result.StatusCodes {
+		result.StatusCodes[code] = count
+	}
+	result.Errors = make(map[string]int, len(run.result.Errors))
+	for kind, count := range run.result.Errors {
+		result.Errors[kind] = count
+	}
+
+	end := time.Now()
+	if !result.FinishedAt.IsZero() {
+		end = result.FinishedAt
+	}
+	elapsed := end.Sub(result.StartedAt)
+	result.Elapsed = float64(elapsed.Microseconds()) / 1000
+	if elapsed > 0 {
+		result.Throughput = float64(result.Completed) / elapsed.Seconds()
+	}
+
+	// Progress uses the running estimate; exact figures are computed once at the end
+	if !run.summarised {
+		result.Latency, result.Histogram = run.stats.summary()
+	}
+	return result
+}
+
+// summarise replaces the estimated latency figures with exact ones once the
+// workers have finished, and releases the recorded latencies
+func (run *benchmarkRun) summarise() {
+	run.mutex.Lock()
+	latencies := run.latencies
+	run.latencies = nil
+	run.mutex.Unlock()
+
+	latency, histogram := summariseLatencies(latencies)
+
+	run.mutex.Lock()
+	run.result.Latency, run.result.Histogram = latency, histogram
+	run.summarised = true
+	run.mutex.Unlock()
+}
+
+// summariseLatencies computes percentiles and a histogram of latencies in
+// milliseconds, sorting latencies in place
+func summariseLatencies(latencies []float64) (BenchmarkLatency, []BenchmarkBucket) {
+	histogram := make([]BenchmarkBucket, len(benchmarkBuckets)+1)
+	for i, bound := range benchmarkBuckets {
+		histogram[i].UpperBoundMs = bound
+	}
+	if len(latencies) == 0 {
+		return BenchmarkLatency{}, histogram
+	}
+
+	sorted := latencies
+	sort.Float64s(sorted)
+
+	total := 0.0
+	for _, latency := range sorted {
+		total += latency
+		bucket := sort.SearchFloat64s(benchmarkBuckets, latency)
+		histogram[bucket].Count++
+	}
+
+	percentile := func(p float64) float64 {
+		index := int(p*float64(len(sorted))+0.5) - 1
+		if index < 0 {
+			index = 0
+		}
+		if index >= len(sorted) {
+			index = len(sorted) - 1
+		}
+		return sorted[index]
+	}
+
+	return BenchmarkLatency{
+		Min:  sorted[0],
+		Mean: total / float64(len(sorted)),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P99:  percentile(0.99),
+		Max:  sorted[len(sorted)-1],
+	}, histogram
+}
+
+// benchmarkErrorType classifies a request error for the error breakdown
+func benchmarkErrorType(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var recordErr tls.RecordHeaderError
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return "connection reset"
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthority), errors.As(err, &recordErr):
+		return "tls"
+	}
+	return "other"
+}
+
+// StartBenchmark starts replaying a request in the background and returns the initial result
+func (at *APITester) StartBenchmark(config BenchmarkConfig) (BenchmarkResult, error) {
+	// Default to 100 requests when no stop condition is given
+	if config.TotalRequests <= 0 && config.DurationSeconds <= 0 {
+		config.TotalRequests = 100
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 10
+	}
+	if config.TotalRequests > 0 && config.Concurrency > config.TotalRequests {
+		config.Concurrency = config.TotalRequests
+	}
+	if config.RateLimit < 0 || math.IsNaN(config.RateLimit) || math.IsInf(config.RateLimit, 0) {
+		return BenchmarkResult{}, fmt.Errorf("invalid rate limit: %v", config.RateLimit)
+	}
+
+	// Build the request once; each iteration sends a clone of it
+	template, err := at.buildHTTPRequest(config.Request)
+	if err != nil {
+		return BenchmarkResult{}, fmt.Errorf("error creating request: %v", err)
+	}
+
+	timeout := 30
+	if config.Request.Timeout > 0 {
+		timeout = config.Request.Timeout
+	}
+	client := &http.Client{
+		Timeout: time.Duration(timeout) * time.Second,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConns:        config.Concurrency,
+			MaxIdleConnsPerHost: config.Concurrency,
+			ForceAttemptHTTP2:   true,
+		},
+	}
+	if config.Request.NoRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if config.DurationSeconds > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, time.Duration(config.DurationSeconds)*time.Second)
+		cancelRun := cancel
+		cancel = func() {
+			cancelTimeout()
+			cancelRun()
+		}
+	}
+
+	run := &benchmarkRun{
+		result: BenchmarkResult{
+			ID:          fmt.Sprintf("bench-%d", time.Now().UnixNano()),
+			Status:      "running",
+			StartedAt:   time.Now(),
+			StatusCodes: make(map[string]int),
+			Errors:      make(map[string]int),
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	at.benchMutex.Lock()
+	at.pruneBenchmarks()
+	at.benchmarks[run.result.ID] = run
+	at.benchMutex.Unlock()
+
+	at.streamMutex.Lock()
+	emit := at.emit
+	at.streamMutex.Unlock()
+
+	// Feed jobs to the workers, honouring the request count and rate limit
+	jobs := make(chan struct{})
+	go func() {
+		defer close(jobs)
+
+		var ticker *time.Ticker
+		if config.RateLimit > 0 {
+			// Rates above one request per nanosecond are as good as unlimited
+			interval := time.Duration(float64(time.Second) / config.RateLimit)
+			if interval < time.Nanosecond {
+				interval = time.Nanosecond
+			}
+			ticker = time.NewTicker(interval)
+			defer ticker.Stop()
+		}
+
+		for sent := 0; config.TotalRequests <= 0 || sent < config.TotalRequests; sent++ {
+			if ticker != nil {
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case jobs <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var workers sync.WaitGroup
+	for i := 0; i < config.Concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for range jobs {
+				req := template.Clone(ctx)
+				if template.GetBody != nil {
+					req.Body, _ = template.GetBody()
+				}
+
+				start := time.Now()
+				resp, err := client.Do(req)
+				var bytes int64
+				statusCode := 0
+				if err == nil {
+					bytes, err = io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+					statusCode = resp.StatusCode
+				}
+
+				// Requests interrupted by the end of the run aren't failures
+				if err != nil && ctx.Err() != nil {
+					continue
+				}
+				run.record(time.Since(start), statusCode, bytes, err)
+			}
+		}()
+	}
+
+	// Emit progress until the workers finish
+	go func() {
+		ticker := time.NewTicker(benchmarkProgressInterval)
+		defer ticker.Stop()
+
+		finished := make(chan struct{})
+		go func() {
+			workers.Wait()
+			close(finished)
+		}()
+
+		for {
+			select {
+			case <-ticker.C:
+				if emit != nil {
+					emit(BenchmarkEvent, run.snapshot())
+				}
+			case <-finished:
+				run.mutex.Lock()
+				run.result.FinishedAt = time.Now()
+				if run.result.Status == "running" {
+					run.result.Status = "completed"
+				}
+				run.mutex.Unlock()
+				run.summarise()
+				cancel()
+				client.CloseIdleConnections()
+				close(run.done)
+
+				if emit != nil {
+					emit(BenchmarkEvent, run.snapshot())
+				}
+				return
+			}
+		}
+	}()
+
+	return run.snapshot(), nil
+}
+
+// pruneBenchmarks forgets the oldest finished benchmarks beyond
+// maxFinishedBenchmarks. The caller must hold benchMutex.
+func (at *APITester) pruneBenchmarks() {
+	type finishedRun struct {
+		id         string
+		finishedAt time.Time
+	}
+	var finished []finishedRun
+	for id, run := range at.benchmarks {
+		select {
+		case <-run.done:
+			run.mutex.Lock()
+			finished = append(finished, finishedRun{id, run.result.FinishedAt})
+			run.mutex.Unlock()
+		default:
+		}
+	}
+	if len(finished) <= maxFinishedBenchmarks {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].finishedAt.Before(finished[j].finishedAt)
+	})
+	for _, run := range finished[:len(finished)-maxFinishedBenchmarks] {
+		delete(at.benchmarks, run.id)
+	}
+}
+
+// CancelBenchmark stops a running benchmark and returns its final result
+func (at *APITester) CancelBenchmark(benchmarkID string) (BenchmarkResult, error) {
+	at.benchMutex.Lock()
+	run, exists := at.benchmarks[benchmarkID]
+	at.benchMutex.Unlock()
+	if !exists {
+		return BenchmarkResult{}, fmt.Errorf("benchmark with ID %s not found", benchmarkID)
+	}
+
+	run.mutex.Lock()
+	if run.result.Status == "running" {
+		run.result.Status = "cancelled"
+	}
+	run.mutex.Unlock()
+
+	run.cancel()
+	<-run.done
+	return run.snapshot(), nil
+}
+
+// GetBenchmark returns the current result of a benchmark
+func (at *APITester) GetBenchmark(benchmarkID string) (BenchmarkResult, error) {
+	at.benchMutex.Lock()
+	run, exists := at.benchmarks[benchmarkID]
+	at.benchMutex.Unlock()
+	if !exists {
+		return BenchmarkResult{}, fmt.Errorf("benchmark with ID %s not found", benchmarkID)
+	}
+	return run.snapshot(), nil
+}
+
+// CancelAllBenchmarks stops every running benchmark
+func (at *APITester) CancelAllBenchmarks() {
+	at.benchMutex.Lock()
+	ids := make([]string, 0, len(at.benchmarks))
+	for id := range at.benchmarks {
+		ids = append(ids, id)
+	}
+	at.benchMutex.Unlock()
+
+	for _, id := range ids {
+		at.CancelBenchmark(id)
+	}
+}
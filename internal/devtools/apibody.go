@@ -0,0 +1,254 @@
+package devtools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// BodyType represents how an API request body is encoded
+type BodyType string
+
+const (
+	BodyRaw       BodyType = "raw"
+	BodyJSON      BodyType = "json"
+	BodyForm      BodyType = "form"
+	BodyMultipart BodyType = "multipart"
+	BodyBinary    BodyType = "binary"
+)
+
+// maxInlineBinaryBody is the largest binary response returned as base64;
+// larger bodies are written to a file instead
+const maxInlineBinaryBody = 1 << 20
+
+// APIFormField is a key/value pair of a form-urlencoded or multipart body.
+// In multipart bodies a field of type "file" is read from FilePath.
+type APIFormField struct {
+	Key         string `json:"key"`
+	Value       string `json:"value,omitempty"`
+	Type        string `json:"type,omitempty"` // "text" or "file"
+	FilePath    string `json:"filePath,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// buildRequestBody encodes the body of req and returns it along with the
+// Content-Type it should be sent with (empty if the caller shouldn't set one)
+func buildRequestBody(req APIRequest) (io.Reader, string, error) {
+	switch req.BodyType {
+	case "":
+		// Requests saved before body types existed default to JSON
+		if req.Body == "" {
+			return nil, "", nil
+		}
+		return strings.NewReader(req.Body), "application/json", nil
+
+	case BodyRaw:
+		if req.Body == "" {
+			return nil, "", nil
+		}
+		return strings.NewReader(req.Body), "text/plain; charset=utf-8", nil
+
+	case BodyJSON:
+		if req.Body == "" {
+			return nil, "", nil
+		}
+		if !isJSON(req.Body) {
+			return nil, "", fmt.Errorf("body is not valid JSON")
+		}
+		return strings.NewReader(req.Body), "application/json", nil
+
+	case BodyForm:
+		values := url.Values{}
+		for _, field := range req.FormFields {
+			if field.Type == "file" {
+				return nil, "", fmt.Errorf("file field %s is not allowed in a form-urlencoded body", field.Key)
+			}
+			values.Add(field.Key, field.Value)
+		}
+		return strings.NewReader(values.Encode()), "application/x-www-form-urlencoded", nil
+
+	case BodyMultipart:
+		return buildMultipartBody(req.FormFields)
+
+	case BodyBinary:
+		if req.FilePath == "" {
+			return nil, "", fmt.Errorf("no file selected for binary body")
+		}
+		data, err := os.ReadFile(expandHome(req.FilePath))
+		if err != nil {
+			return nil, "", fmt.Errorf("error reading body file: %v", err)
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(req.FilePath))
+		if contentType == "" {
+			contentType = http.DetectContentType(data)
+		}
+		return bytes.NewReader(data), contentType, nil
+
+	default:
+		return nil, "", fmt.Errorf("unsupported body type: %s", req.BodyType)
+	}
+}
+
+// buildMultipartBody encodes fields as multipart/form-data, reading file parts from disk
+func buildMultipartBody(fields []APIFormField) (io.Reader, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	for _, field := range fields {
+		if field.Type != "file" {
+			if err := writer.WriteField(field.Key, field.Value); err != nil {
+				return nil, "", fmt.Errorf("error writing form field %s: %v", field.Key, err)
+			}
+			continue
+		}
+
+		data, err := os.ReadFile(expandHome(field.FilePath))
+		if err != nil {
+			return nil, "", fmt.Errorf("error reading file for field %s: %v", field.Key, err)
+		}
+
+		contentType := field.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(field.FilePath))
+		}
+		if contentType == "" {
+			contentType = http.DetectContentType(data)
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+			"name":     field.Key,
+			"filename": filepath.Base(field.FilePath),
+		}))
+		header.Set("Content-Type", contentType)
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", fmt.Errorf("error creating file part %s: %v", field.Key, err)
+		}
+		if _, err := part.Write(data); err != nil {
+			return nil, "", fmt.Errorf("error writing file part %s: %v", field.Key, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("error finishing multipart body: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes()), writer.FormDataContentType(), nil
+}
+
+// expandHome expands a leading ~/ to the user's home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// isTextContent reports whether a response with the given media type and body
+// can be shown as text
+func isTextContent(mediaType string, body []byte) bool {
+	switch {
+	case mediaType == "":
+		return utf8.Valid(body) && strings.HasPrefix(http.DetectContentType(body), "text/")
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/x-www-form-urlencoded", "application/graphql", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// applyResponseBody fills in the body fields of response. Text bodies are returned
+// as a string (pretty-printed if JSON); binary bodies are returned as base64 or,
+// when large or when the request asks for it, saved to a file.
+func applyResponseBody(response *APIResponse, resp *http.Response, body []byte, saveTo string) error {
+	response.Size = int64(len(body))
+
+	mediaType := ""
+	if header := resp.Header.Get("Content-Type"); header != "" {
+		if parsed, _, err := mime.ParseMediaType(header); err == nil {
+			mediaType = parsed
+		}
+	}
+	response.ContentType = mediaType
+	if response.ContentType == "" && len(body) > 0 {
+		response.ContentType = http.DetectContentType(body)
+	}
+
+	// A body with an encoding the transport didn't decode is never readable text
+	encoded := resp.Header.Get("Content-Encoding") != "" && !resp.Uncompressed
+	response.IsBinary = len(body) > 0 && (encoded || !isTextContent(mediaType, body))
+
+	if saveTo != "" || (response.IsBinary && len(body) > maxInlineBinaryBody) {
+		path, err := saveResponseBody(body, saveTo, response.ContentType)
+		if err != nil {
+			return err
+		}
+		response.SavedPath = path
+		return nil
+	}
+
+	if response.IsBinary {
+		response.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+		return nil
+	}
+
+	// Format JSON response if possible
+	response.Body = string(body)
+	if isJSON(response.Body) {
+		var jsonObj interface{}
+		if err := json.Unmarshal(body, &jsonObj); err == nil {
+			if formattedJSON, err := json.MarshalIndent(jsonObj, "", "  "); err == nil {
+				response.Body = string(formattedJSON)
+			}
+		}
+	}
+	return nil
+}
+
+// saveResponseBody writes body to path, or to a generated file under
+// ~/.devex/responses when path is empty, and returns the file written
+func saveResponseBody(body []byte, path, contentType string) (string, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error getting home directory: %v", err)
+		}
+
+		ext := ".bin"
+		if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) > 0 {
+			ext = exts[0]
+		}
+		path = filepath.Join(home, ".devex", "responses", fmt.Sprintf("response-%d%s", time.Now().UnixNano(), ext))
+	}
+
+	path = expandHome(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("error creating response directory: %v", err)
+	}
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		return "", fmt.Errorf("error saving response body: %v", err)
+	}
+	return path, nil
+}
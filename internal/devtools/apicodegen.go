@@ -0,0 +1,634 @@
+package devtools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CodeLanguage is a target language for generated request code
+type CodeLanguage string
+
+const (
+	CodeCurl   CodeLanguage = "curl"
+	CodeGo     CodeLanguage = "go"
+	CodeFetch  CodeLanguage = "fetch"
+	CodePython CodeLanguage = "python"
+)
+
+// Environment variables that generated code reads secrets from, so that
+// snippets can be shared without leaking credentials
+const (
+	codeEnvPassword = "API_PASSWORD"
+	codeEnvToken    = "API_TOKEN"
+	codeEnvAPIKey   = "API_KEY"
+)
+
+// codePart is a piece of a generated string value: literal text or an environment variable
+type codePart struct {
+	text string
+	env  string
+}
+
+// codeValue is a string value built from literal text and environment variables
+type codeValue []codePart
+
+// codeLiteral returns a value made of literal text only
+func codeLiteral(text string) codeValue {
+	return codeValue{{text: text}}
+}
+
+// codeHeader is a header of a generated request
+type codeHeader struct {
+	name  string
+	value codeValue
+}
+
+// codeRequest is an APIRequest resolved into what every generator needs
+type codeRequest struct {
+	method      string
+	url         codeValue
+	headers     []codeHeader
+	basicUser   string
+	basicAuth   bool
+	bodyType    BodyType
+	body        string
+	fields      []APIFormField
+	filePath    string
+	timeout     int
+	noRedirects bool
+	contentType bool // the request sets its own Content-Type
+}
+
+// GenerateCode renders req as a snippet in the given language. Secrets are
+// read from API_PASSWORD, API_TOKEN or API_KEY rather than embedded.
+func (at *APITester) GenerateCode(req APIRequest, language CodeLanguage) (string, error) {
+	cr, err := newCodeRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	switch language {
+	case CodeCurl:
+		return cr.curl(), nil
+	case CodeGo:
+		return cr.goCode(), nil
+	case CodeFetch:
+		return cr.fetch(), nil
+	case CodePython:
+		return cr.python(), nil
+	default:
+		return "", fmt.Errorf("unsupported language: %s", language)
+	}
+}
+
+// newCodeRequest resolves the body type, default headers and auth of req
+func newCodeRequest(req APIRequest) (codeRequest, error) {
+	if req.URL == "" {
+		return codeRequest{}, fmt.Errorf("request has no URL")
+	}
+
+	cr := codeRequest{
+		method:      strings.ToUpper(string(req.Method)),
+		url:         codeLiteral(req.URL),
+		bodyType:    req.BodyType,
+		body:        req.Body,
+		fields:      req.FormFields,
+		timeout:     req.Timeout,
+		noRedirects: req.NoRedirects,
+	}
+	if cr.method == "" {
+		cr.method = "GET"
+	}
+
+	// Requests saved before body types existed send their body as JSON
+	defaultContentType := ""
+	switch cr.bodyType {
+	case "":
+		if cr.body != "" {
+			cr.bodyType = BodyJSON
+			defaultContentType = "application/json"
+		}
+	case BodyRaw:
+		defaultContentType = "text/plain; charset=utf-8"
+	case BodyJSON:
+		defaultContentType = "application/json"
+	case BodyForm, BodyMultipart:
+		// The generated code sets these itself
+	case BodyBinary:
+		if req.FilePath == "" {
+			return codeRequest{}, fmt.Errorf("no file selected for binary body")
+		}
+		cr.filePath = expandHome(req.FilePath)
+		defaultContentType = mime.TypeByExtension(filepath.Ext(req.FilePath))
+		if defaultContentType == "" {
+			defaultContentType = "application/octet-stream"
+		}
+	default:
+		return codeRequest{}, fmt.Errorf("unsupported body type: %s", req.BodyType)
+	}
+	if (cr.bodyType == BodyRaw || cr.bodyType == BodyJSON) && cr.body == "" {
+		cr.bodyType = ""
+		defaultContentType = ""
+	}
+
+	// Set headers in a stable order
+	names := make([]string, 0, len(req.Headers))
+	hasContentType := false
+	for name := range req.Headers {
+		names = append(names, name)
+		if strings.EqualFold(name, "Content-Type") {
+			hasContentType = true
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cr.headers = append(cr.headers, codeHeader{name: name, value: codeLiteral(req.Headers[name])})
+	}
+	cr.contentType = hasContentType
+	if defaultContentType != "" && !hasContentType {
+		cr.headers = append(cr.headers, codeHeader{name: "Content-Type", value: codeLiteral(defaultContentType)})
+	}
+
+	// Apply authentication
+	if auth := req.Auth; auth != nil {
+		switch auth.Type {
+		case "", AuthNone:
+		case AuthBasic:
+			cr.basicAuth = true
+			cr.basicUser = auth.Username
+		case AuthBearer, AuthOAuth2:
+			cr.headers = append(cr.headers, codeHeader{
+				name:  "Authorization",
+				value: codeValue{{text: "Bearer "}, {env: codeEnvToken}},
+			})
+		case AuthAPIKey:
+			if auth.APIKeyName == "" {
+				return codeRequest{}, fmt.Errorf("API key name is not set")
+			}
+			if auth.APIKeyIn == "query" {
+				separator := "?"
+				if strings.Contains(req.URL, "?") {
+					separator = "&"
+				}
+				cr.url = codeValue{
+					{text: req.URL + separator + url.QueryEscape(auth.APIKeyName) + "="},
+					{env: codeEnvAPIKey},
+				}
+			} else {
+				cr.headers = append(cr.headers, codeHeader{name: auth.APIKeyName, value: codeValue{{env: codeEnvAPIKey}}})
+			}
+		default:
+			return codeRequest{}, fmt.Errorf("unsupported auth type: %s", auth.Type)
+		}
+	}
+
+	return cr, nil
+}
+
+// usesEnv reports whether any value of the request reads an environment variable
+func (cr codeRequest) usesEnv() bool {
+	if cr.basicAuth {
+		return true
+	}
+	values := []codeValue{cr.url}
+	for _, header := range cr.headers {
+		values = append(values, header.value)
+	}
+	for _, value := range values {
+		for _, part := range value {
+			if part.env != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasFileFields reports whether a multipart body has file parts
+func (cr codeRequest) hasFileFields() bool {
+	if cr.bodyType != BodyMultipart {
+		return false
+	}
+	for _, field := range cr.fields {
+		if field.Type == "file" {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteString returns s as a double-quoted string literal that is valid in
+// Go, JavaScript and Python
+func quoteString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// merged joins adjacent literal parts of a value
+func (value codeValue) merged() codeValue {
+	result := codeValue{}
+	for _, part := range value {
+		last := len(result) - 1
+		if part.env == "" && last >= 0 && result[last].env == "" {
+			result[last].text += part.text
+			continue
+		}
+		result = append(result, part)
+	}
+	return result
+}
+
+// shellValue renders a value as a single shell word
+func shellValue(value codeValue) string {
+	var sb strings.Builder
+	for _, part := range value.merged() {
+		if part.env != "" {
+			sb.WriteString(`"$` + part.env + `"`)
+		} else if part.text != "" {
+			sb.WriteString(shellQuote(part.text))
+		}
+	}
+	if sb.Len() == 0 {
+		return "''"
+	}
+	return sb.String()
+}
+
+// joinValue renders a value as a concatenation of string literals and env lookups
+func joinValue(value codeValue, env func(name string) string) string {
+	parts := make([]string, 0, len(value))
+	for _, part := range value.merged() {
+		if part.env != "" {
+			parts = append(parts, env(part.env))
+		} else if part.text != "" {
+			parts = append(parts, quoteString(part.text))
+		}
+	}
+	if len(parts) == 0 {
+		return `""`
+	}
+	return strings.Join(parts, " + ")
+}
+
+// curl renders the request as a curl command
+func (cr codeRequest) curl() string {
+	args := []string{}
+
+	switch {
+	case cr.method == "HEAD":
+		args = append(args, "--head")
+	case cr.method != "GET" || cr.bodyType != "":
+		args = append(args, "-X "+cr.method)
+	}
+	if !cr.noRedirects {
+		args = append(args, "-L")
+	}
+	if cr.timeout > 0 {
+		args = append(args, fmt.Sprintf("--max-time %d", cr.timeout))
+	}
+
+	for _, header := range cr.headers {
+		args = append(args, "-H "+shellValue(append(codeLiteral(header.name+": "), header.value...)))
+	}
+	if cr.basicAuth {
+		args = append(args, "-u "+shellValue(codeValue{{text: cr.basicUser + ":"}, {env: codeEnvPassword}}))
+	}
+
+	switch cr.bodyType {
+	case BodyRaw, BodyJSON:
+		args = append(args, "--data-raw "+shellQuote(cr.body))
+	case BodyForm:
+		for _, field := range cr.fields {
+			args = append(args, "--data-urlencode "+shellQuote(url.QueryEscape(field.Key)+"="+field.Value))
+		}
+	case BodyMultipart:
+		for _, field := range cr.fields {
+			if field.Type != "file" {
+				args = append(args, "--form-string "+shellQuote(field.Key+"="+field.Value))
+				continue
+			}
+			value := field.Key + `=@"` + strings.ReplaceAll(expandHome(field.FilePath), `"`, `\"`) + `"`
+			if field.ContentType != "" {
+				value += ";type=" + field.ContentType
+			}
+			args = append(args, "-F "+shellQuote(value))
+		}
+	case BodyBinary:
+		args = append(args, "--data-binary "+shellQuote("@"+cr.filePath))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("curl " + shellValue(cr.url))
+	for _, arg := range args {
+		sb.WriteString(" \\\n  " + arg)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// goCode renders the request as a Go program using net/http
+func (cr codeRequest) goCode() string {
+	imports := map[string]bool{"fmt": true, "io": true, "net/http": true}
+	env := func(name string) string {
+		imports["os"] = true
+		return fmt.Sprintf("os.Getenv(%s)", quoteString(name))
+	}
+
+	var body strings.Builder
+	line := func(format string, args ...interface{}) {
+		body.WriteString("\t" + fmt.Sprintf(format, args...) + "\n")
+	}
+	panicOnErr := func(indent string) {
+		body.WriteString(indent + "\tif err != nil {\n" + indent + "\t\tpanic(err)\n" + indent + "\t}\n")
+	}
+
+	// Build the body
+	bodyVar := "nil"
+	switch cr.bodyType {
+	case BodyRaw, BodyJSON:
+		imports["strings"] = true
+		line("body := strings.NewReader(%s)", quoteString(cr.body))
+		bodyVar = "body"
+	case BodyForm:
+		imports["net/url"] = true
+		imports["strings"] = true
+		line("form := url.Values{}")
+		for _, field := range cr.fields {
+			line("form.Add(%s, %s)", quoteString(field.Key), quoteString(field.Value))
+		}
+		line("body := strings.NewReader(form.Encode())")
+		bodyVar = "body"
+	case BodyMultipart:
+		imports["bytes"] = true
+		imports["mime/multipart"] = true
+		line("body := &bytes.Buffer{}")
+		line("writer := multipart.NewWriter(body)")
+		for _, field := range cr.fields {
+			if field.Type != "file" {
+				line("writer.WriteField(%s, %s)", quoteString(field.Key), quoteString(field.Value))
+				continue
+			}
+			imports["os"] = true
+			line("{")
+			line("\tdata, err := os.ReadFile(%s)", quoteString(expandHome(field.FilePath)))
+			panicOnErr("\t")
+			if field.ContentType != "" {
+				imports["net/textproto"] = true
+				disposition := mime.FormatMediaType("form-data", map[string]string{
+					"name":     field.Key,
+					"filename": filepath.Base(field.FilePath),
+				})
+				line("\theader := make(textproto.MIMEHeader)")
+				line("\theader.Set(\"Content-Disposition\", %s)", quoteString(disposition))
+				line("\theader.Set(\"Content-Type\", %s)", quoteString(field.ContentType))
+				line("\tpart, err := writer.CreatePart(header)")
+			} else {
+				line("\tpart, err := writer.CreateFormFile(%s, %s)", quoteString(field.Key), quoteString(filepath.Base(field.FilePath)))
+			}
+			panicOnErr("\t")
+			line("\tpart.Write(data)")
+			line("}")
+		}
+		line("writer.Close()")
+		bodyVar = "body"
+	case BodyBinary:
+		imports["os"] = true
+		line("body, err := os.Open(%s)", quoteString(cr.filePath))
+		panicOnErr("")
+		line("defer body.Close()")
+		bodyVar = "body"
+	}
+	if bodyVar != "nil" {
+		body.WriteString("\n")
+	}
+
+	// Create the request
+	line("req, err := http.NewRequest(%s, %s, %s)", quoteString(cr.method), joinValue(cr.url, env), bodyVar)
+	panicOnErr("")
+	for _, header := range cr.headers {
+		line("req.Header.Set(%s, %s)", quoteString(header.name), joinValue(header.value, env))
+	}
+	if cr.bodyType == BodyMultipart {
+		line("req.Header.Set(\"Content-Type\", writer.FormDataContentType())")
+	}
+	if cr.bodyType == BodyForm && !cr.contentType {
+		line("req.Header.Set(\"Content-Type\", \"application/x-www-form-urlencoded\")")
+	}
+	if cr.basicAuth {
+		line("req.SetBasicAuth(%s, %s)", quoteString(cr.basicUser), env(codeEnvPassword))
+	}
+	body.WriteString("\n")
+
+	// Send it
+	line("client := &http.Client{")
+	if cr.timeout > 0 {
+		imports["time"] = true
+		line("\tTimeout: %d * time.Second,", cr.timeout)
+	}
+	if cr.noRedirects {
+		line("\tCheckRedirect: func(*http.Request, []*http.Request) error {")
+		line("\t\treturn http.ErrUseLastResponse")
+		line("\t},")
+	}
+	line("}")
+	line("resp, err := client.Do(req)")
+	panicOnErr("")
+	line("defer resp.Body.Close()")
+	body.WriteString("\n")
+	line("data, err := io.ReadAll(resp.Body)")
+	panicOnErr("")
+	line("fmt.Println(resp.Status)")
+	line("fmt.Println(string(data))")
+
+	packages := make([]string, 0, len(imports))
+	for pkg := range imports {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+
+	var sb strings.Builder
+	sb.WriteString("package main\n\nimport (\n")
+	for _, pkg := range packages {
+		sb.WriteString("\t" + quoteString(pkg) + "\n")
+	}
+	sb.WriteString(")\n\nfunc main() {\n")
+	sb.WriteString(body.String())
+	sb.WriteString("}\n")
+
+	// Let gofmt settle spacing and alignment
+	if formatted, err := format.Source([]byte(sb.String())); err == nil {
+		return string(formatted)
+	}
+	return sb.String()
+}
+
+// fetch renders the request as JavaScript using fetch (Node.js 18+ for env and files)
+func (cr codeRequest) fetch() string {
+	env := func(name string) string {
+		return "process.env." + name
+	}
+
+	var sb strings.Builder
+	if cr.bodyType == BodyBinary || cr.hasFileFields() {
+		sb.WriteString("import fs from \"node:fs/promises\";\n\n")
+	}
+
+	// Multipart bodies are built before the call
+	if cr.bodyType == BodyMultipart {
+		sb.WriteString("const form = new FormData();\n")
+		for _, field := range cr.fields {
+			if field.Type != "file" {
+				sb.WriteString(fmt.Sprintf("form.append(%s, %s);\n", quoteString(field.Key), quoteString(field.Value)))
+				continue
+			}
+			options := ""
+			if field.ContentType != "" {
+				options = fmt.Sprintf(", { type: %s }", quoteString(field.ContentType))
+			}
+			sb.WriteString(fmt.Sprintf("form.append(%s, new Blob([await fs.readFile(%s)]%s), %s);\n",
+				quoteString(field.Key), quoteString(expandHome(field.FilePath)), options, quoteString(filepath.Base(field.FilePath))))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("const response = await fetch(%s, {\n", joinValue(cr.url, env)))
+	sb.WriteString(fmt.Sprintf("  method: %s,\n", quoteString(cr.method)))
+
+	if len(cr.headers) > 0 || cr.basicAuth {
+		sb.WriteString("  headers: {\n")
+		for _, header := range cr.headers {
+			sb.WriteString(fmt.Sprintf("    %s: %s,\n", quoteString(header.name), joinValue(header.value, env)))
+		}
+		if cr.basicAuth {
+			sb.WriteString(fmt.Sprintf("    \"Authorization\": \"Basic \" + btoa(%s + %s),\n", quoteString(cr.basicUser+":"), env(codeEnvPassword)))
+		}
+		sb.WriteString("  },\n")
+	}
+
+	switch cr.bodyType {
+	case BodyRaw, BodyJSON:
+		sb.WriteString(fmt.Sprintf("  body: %s,\n", quoteString(cr.body)))
+	case BodyForm:
+		pairs := make([]string, 0, len(cr.fields))
+		for _, field := range cr.fields {
+			pairs = append(pairs, fmt.Sprintf("[%s, %s]", quoteString(field.Key), quoteString(field.Value)))
+		}
+		sb.WriteString(fmt.Sprintf("  body: new URLSearchParams([%s]),\n", strings.Join(pairs, ", ")))
+	case BodyMultipart:
+		sb.WriteString("  body: form,\n")
+	case BodyBinary:
+		sb.WriteString(fmt.Sprintf("  body: await fs.readFile(%s),\n", quoteString(cr.filePath)))
+	}
+
+	if cr.noRedirects {
+		sb.WriteString("  redirect: \"manual\",\n")
+	}
+	if cr.timeout > 0 {
+		sb.WriteString(fmt.Sprintf("  signal: AbortSignal.timeout(%d),\n", cr.timeout*1000))
+	}
+	sb.WriteString("});\n\n")
+	sb.WriteString("console.log(response.status);\n")
+	sb.WriteString("console.log(await response.text());\n")
+	return sb.String()
+}
+
+// python renders the request as Python using the requests library
+func (cr codeRequest) python() string {
+	env := func(name string) string {
+		return fmt.Sprintf("os.environ[%s]", quoteString(name))
+	}
+
+	args := []string{joinValue(cr.url, env)}
+
+	if len(cr.headers) > 0 {
+		var headers strings.Builder
+		headers.WriteString("headers={\n")
+		for _, header := range cr.headers {
+			headers.WriteString(fmt.Sprintf("        %s: %s,\n", quoteString(header.name), joinValue(header.value, env)))
+		}
+		headers.WriteString("    }")
+		args = append(args, headers.String())
+	}
+	if cr.basicAuth {
+		args = append(args, fmt.Sprintf("auth=(%s, %s)", quoteString(cr.basicUser), env(codeEnvPassword)))
+	}
+
+	pairs := func(fileFields bool) string {
+		items := []string{}
+		for _, field := range cr.fields {
+			if (field.Type == "file") != fileFields {
+				continue
+			}
+			if !fileFields {
+				items = append(items, fmt.Sprintf("(%s, %s)", quoteString(field.Key), quoteString(field.Value)))
+				continue
+			}
+			file := fmt.Sprintf("%s, open(%s, \"rb\")", quoteString(filepath.Base(field.FilePath)), quoteString(expandHome(field.FilePath)))
+			if field.ContentType != "" {
+				file += ", " + quoteString(field.ContentType)
+			}
+			items = append(items, fmt.Sprintf("(%s, (%s))", quoteString(field.Key), file))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+
+	switch cr.bodyType {
+	case BodyRaw, BodyJSON:
+		args = append(args, fmt.Sprintf("data=%s", quoteString(cr.body)))
+	case BodyForm:
+		args = append(args, "data="+pairs(false))
+	case BodyMultipart:
+		args = append(args, "data="+pairs(false))
+		if cr.hasFileFields() {
+			args = append(args, "files="+pairs(true))
+		}
+	case BodyBinary:
+		args = append(args, fmt.Sprintf("data=open(%s, \"rb\")", quoteString(cr.filePath)))
+	}
+
+	if cr.timeout > 0 {
+		args = append(args, fmt.Sprintf("timeout=%d", cr.timeout))
+	}
+	if cr.noRedirects {
+		args = append(args, "allow_redirects=False")
+	} else if cr.method == "HEAD" {
+		args = append(args, "allow_redirects=True")
+	}
+
+	call := "requests." + strings.ToLower(cr.method)
+	switch cr.method {
+	case "GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS":
+	default:
+		call = "requests.request"
+		args = append([]string{quoteString(cr.method)}, args...)
+	}
+
+	var sb strings.Builder
+	if cr.usesEnv() {
+		sb.WriteString("import os\n\n")
+	}
+	sb.WriteString("import requests\n\n")
+	sb.WriteString("response = " + call + "(\n")
+	for _, arg := range args {
+		sb.WriteString("    " + arg + ",\n")
+	}
+	sb.WriteString(")\n\n")
+	sb.WriteString("print(response.status_code)\n")
+	sb.WriteString("print(response.text)\n")
+	return sb.String()
+}
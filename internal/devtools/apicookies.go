@@ -0,0 +1,292 @@
+package devtools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// maxRedirects is the number of redirects followed before giving up
+const maxRedirects = 10
+
+// APICookie represents a cookie set by a response
+type APICookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Expires  time.Time `json:"expires,omitempty"`
+	MaxAge   int       `json:"maxAge,omitempty"`
+	Secure   bool      `json:"secure"`
+	HttpOnly bool      `json:"httpOnly"`
+	SameSite string    `json:"sameSite,omitempty"`
+}
+
+// APIRedirect is one hop of a redirect chain
+type APIRedirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"statusCode"`
+	Status     string `json:"status"`
+	Location   string `json:"location"`
+}
+
+// convertCookies converts the cookies set by a response
+func convertCookies(cookies []*http.Cookie) []APICookie {
+	result := make([]APICookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		result = append(result, APICookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Expires:  cookie.Expires,
+			MaxAge:   cookie.MaxAge,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			SameSite: sameSiteName(cookie.SameSite),
+		})
+	}
+	return result
+}
+
+// sameSiteName returns the attribute value for a SameSite mode
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
+
+// redirectRecorder returns a CheckRedirect function that records each hop
+// into chain, or stops at the first redirect when follow is false
+func redirectRecorder(chain *[]APIRedirect, follow bool) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if req.Response != nil {
+			*chain = append(*chain, APIRedirect{
+				URL:        via[len(via)-1].URL.String(),
+				StatusCode: req.Response.StatusCode,
+				Status:     req.Response.Status,
+				Location:   req.URL.String(),
+			})
+		}
+
+		if !follow {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+}
+
+// storedCookie is a cookie saved to disk with the URL of the response that set
+// it, so that the jar can apply the same domain and path rules when loading it
+type storedCookie struct {
+	URL    string    `json:"url"`
+	Cookie APICookie `json:"cookie"`
+}
+
+// persistentJar is a cookie jar that saves its cookies to a file
+type persistentJar struct {
+	jar     *cookiejar.Jar
+	path    string
+	cookies map[string]storedCookie // keyed by domain, path and name
+	mutex   sync.Mutex
+}
+
+// newPersistentJar creates a jar holding the unexpired cookies saved at path
+func newPersistentJar(path string) *persistentJar {
+	// cookiejar.New only fails for invalid options
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	pj := &persistentJar{jar: jar, path: path, cookies: make(map[string]storedCookie)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error reading cookie file: %v\n", err)
+		}
+		return pj
+	}
+	var stored []storedCookie
+	if err := json.Unmarshal(data, &stored); err != nil {
+		fmt.Printf("Error parsing cookie file: %v\n", err)
+		return pj
+	}
+
+	now := time.Now()
+	for _, entry := range stored {
+		u, err := url.Parse(entry.URL)
+		if err != nil || (!entry.Cookie.Expires.IsZero() && entry.Cookie.Expires.Before(now)) {
+			continue
+		}
+		pj.jar.SetCookies(u, []*http.Cookie{entry.Cookie.httpCookie()})
+		pj.cookies[cookieKey(u, entry.Cookie.Domain, entry.Cookie.Path, entry.Cookie.Name)] = entry
+	}
+	return pj
+}
+
+// SetCookies stores the cookies of a response and saves the jar
+func (pj *persistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	pj.jar.SetCookies(u, cookies)
+
+	pj.mutex.Lock()
+	defer pj.mutex.Unlock()
+
+	now := time.Now()
+	for _, cookie := range cookies {
+		stored := convertCookies([]*http.Cookie{cookie})[0]
+		// Max-Age is relative to now, so it is saved as an expiry time
+		stored.MaxAge = 0
+		if cookie.MaxAge > 0 {
+			stored.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+		}
+
+		key := cookieKey(u, cookie.Domain, cookie.Path, cookie.Name)
+		if cookie.MaxAge < 0 || (!stored.Expires.IsZero() && stored.Expires.Before(now)) {
+			delete(pj.cookies, key)
+			continue
+		}
+		pj.cookies[key] = storedCookie{URL: u.String(), Cookie: stored}
+	}
+
+	if err := pj.save(); err != nil {
+		fmt.Printf("Error saving cookies: %v\n", err)
+	}
+}
+
+// Cookies returns the cookies to send to u
+func (pj *persistentJar) Cookies(u *url.URL) []*http.Cookie {
+	return pj.jar.Cookies(u)
+}
+
+// save writes the cookies to the jar's file, readable only by the current user
+func (pj *persistentJar) save() error {
+	if err := os.MkdirAll(filepath.Dir(pj.path), 0755); err != nil {
+		return fmt.Errorf("error creating cookie directory: %v", err)
+	}
+
+	stored := make([]storedCookie, 0, len(pj.cookies))
+	for _, entry := range pj.cookies {
+		stored = append(stored, entry)
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding cookies: %v", err)
+	}
+
+	if err := os.WriteFile(pj.path, data, 0600); err != nil {
+		return fmt.Errorf("error writing cookie file: %v", err)
+	}
+	return nil
+}
+
+// cookieKey identifies a cookie the way a jar does: host-only cookies by the
+// host that set them, others by their domain
+func cookieKey(u *url.URL, domain, path, name string) string {
+	if domain == "" {
+		domain = u.Hostname()
+	}
+	return strings.ToLower(strings.TrimPrefix(domain, ".")) + "\x00" + path + "\x00" + name
+}
+
+// httpCookie converts a saved cookie back for the jar
+func (c APICookie) httpCookie() *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		Expires:  c.Expires,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+	}
+	switch c.SameSite {
+	case "Lax":
+		cookie.SameSite = http.SameSiteLaxMode
+	case "Strict":
+		cookie.SameSite = http.SameSiteStrictMode
+	case "None":
+		cookie.SameSite = http.SameSiteNoneMode
+	}
+	return cookie
+}
+
+// cookieDir returns the directory cookie jars are saved in
+func cookieDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Printf("Error getting user home directory: %v\n", err)
+		home = "."
+	}
+	return filepath.Join(home, ".devex", "cookies")
+}
+
+// cookieFile returns the file an environment's cookies are saved in. The name
+// is hashed since environment names may contain any character.
+func (at *APITester) cookieFile(environment string) string {
+	hash := sha256.Sum256([]byte(environment))
+	return filepath.Join(at.cookieDir, hex.EncodeToString(hash[:8])+".json")
+}
+
+// cookieJar returns the cookie jar for an environment, loading its saved
+// cookies on first use. Requests without an environment don't keep cookies.
+func (at *APITester) cookieJar(environment string) http.CookieJar {
+	if environment == "" {
+		return nil
+	}
+	return at.environmentJar(environment)
+}
+
+// environmentJar returns the jar of a non-empty environment
+func (at *APITester) environmentJar(environment string) *persistentJar {
+	at.jarMutex.Lock()
+	defer at.jarMutex.Unlock()
+
+	jar, exists := at.jars[environment]
+	if !exists {
+		jar = newPersistentJar(at.cookieFile(environment))
+		at.jars[environment] = jar
+	}
+	return jar
+}
+
+// GetCookies returns the cookies an environment's jar would send to rawURL
+func (at *APITester) GetCookies(environment, rawURL string) ([]APICookie, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+	if environment == "" {
+		return []APICookie{}, nil
+	}
+
+	return convertCookies(at.environmentJar(environment).Cookies(u)), nil
+}
+
+// ClearCookies discards the cookie jar of an environment and its saved cookies
+func (at *APITester) ClearCookies(environment string) {
+	at.jarMutex.Lock()
+	defer at.jarMutex.Unlock()
+	delete(at.jars, environment)
+
+	if err := os.Remove(at.cookieFile(environment)); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Error removing cookie file: %v\n", err)
+	}
+}
@@ -0,0 +1,593 @@
+package devtools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// GraphQLRequest represents a GraphQL operation sent over HTTP
+type GraphQLRequest struct {
+	URL            string                 `json:"url"`
+	Headers        map[string]string      `json:"headers"`
+	Query          string                 `json:"query"`
+	Variables      map[string]interface{} `json:"variables,omitempty"`
+	OperationName  string                 `json:"operationName,omitempty"`
+	Timeout        int                    `json:"timeout"` // in seconds
+	Auth           *APIAuth               `json:"auth,omitempty"`
+	Environment    string                 `json:"environment,omitempty"`
+	SkipValidation bool                   `json:"skipValidation,omitempty"`
+}
+
+// GraphQLArgument describes an argument of a field
+type GraphQLArgument struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	DefaultValue string `json:"defaultValue,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// GraphQLOperation describes a root field that can be queried, mutated or subscribed to
+type GraphQLOperation struct {
+	Name              string            `json:"name"`
+	Kind              string            `json:"kind"` // "query", "mutation" or "subscription"
+	Description       string            `json:"description,omitempty"`
+	Arguments         []GraphQLArgument `json:"arguments"`
+	ReturnType        string            `json:"returnType"`
+	Deprecated        bool              `json:"deprecated,omitempty"`
+	DeprecationReason string            `json:"deprecationReason,omitempty"`
+}
+
+// GraphQLSchemaInfo summarises an introspected schema
+type GraphQLSchemaInfo struct {
+	URL        string             `json:"url"`
+	FetchedAt  time.Time          `json:"fetchedAt"`
+	Operations []GraphQLOperation `json:"operations"`
+	Types      []string           `json:"types"`
+}
+
+// graphQLSchema is a cached, introspected schema
+type graphQLSchema struct {
+	info   GraphQLSchemaInfo
+	schema *ast.Schema
+}
+
+// graphQLFailureTTL is how long a failed introspection is remembered before
+// the endpoint is introspected again
+const graphQLFailureTTL = 5 * time.Minute
+
+// graphQLFailure is a failed introspection of an endpoint
+type graphQLFailure struct {
+	err error
+	at  time.Time
+}
+
+// graphQLCache caches introspected schemas, and introspection failures, by endpoint URL
+type graphQLCache struct {
+	schemas  map[string]*graphQLSchema
+	failures map[string]graphQLFailure
+	mutex    sync.Mutex
+}
+
+// introspectionQuery is the standard query used to fetch a schema
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      locations
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+            ofType {
+              kind
+              name
+              ofType {
+                kind
+                name
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// introspectionTypeRef is a (possibly wrapped) type reference
+type introspectionTypeRef struct {
+	Kind   string                `json:"kind"`
+	Name   string                `json:"name"`
+	OfType *introspectionTypeRef `json:"ofType"`
+}
+
+// introspectionInputValue is an argument or input field
+type introspectionInputValue struct {
+	Name         string               `json:"name"`
+	Description  string               `json:"description"`
+	Type         introspectionTypeRef `json:"type"`
+	DefaultValue *string              `json:"defaultValue"`
+}
+
+// introspectionField is a field of an object or interface type
+type introspectionField struct {
+	Name              string                    `json:"name"`
+	Description       string                    `json:"description"`
+	Args              []introspectionInputValue `json:"args"`
+	Type              introspectionTypeRef      `json:"type"`
+	IsDeprecated      bool                      `json:"isDeprecated"`
+	DeprecationReason string                    `json:"deprecationReason"`
+}
+
+// introspectionType is a named type in the schema
+type introspectionType struct {
+	Kind          string                    `json:"kind"`
+	Name          string                    `json:"name"`
+	Fields        []introspectionField      `json:"fields"`
+	InputFields   []introspectionInputValue `json:"inputFields"`
+	Interfaces    []introspectionTypeRef    `json:"interfaces"`
+	EnumValues    []struct{ Name string }   `json:"enumValues"`
+	PossibleTypes []introspectionTypeRef    `json:"possibleTypes"`
+}
+
+// introspectionDirective is a directive declared by the schema
+type introspectionDirective struct {
+	Name      string                    `json:"name"`
+	Locations []string                  `json:"locations"`
+	Args      []introspectionInputValue `json:"args"`
+}
+
+// introspectionSchema is the __schema object of an introspection result
+type introspectionSchema struct {
+	QueryType        *struct{ Name string }   `json:"queryType"`
+	MutationType     *struct{ Name string }   `json:"mutationType"`
+	SubscriptionType *struct{ Name string }   `json:"subscriptionType"`
+	Types            []introspectionType      `json:"types"`
+	Directives       []introspectionDirective `json:"directives"`
+}
+
+// introspectionResponse is the JSON envelope of an introspection result
+type introspectionResponse struct {
+	Data struct {
+		Schema *introspectionSchema `json:"__schema"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// builtinSchema holds the types and directives every GraphQL schema has, which
+// must not be redeclared when rebuilding a schema from introspection
+var builtinSchema = gqlparser.MustLoadSchema()
+
+// toAPIRequest converts a GraphQL request into the HTTP request that carries it
+func (req GraphQLRequest) toAPIRequest(query string, variables map[string]interface{}, operationName string) (APIRequest, error) {
+	payload := map[string]interface{}{"query": query}
+	if len(variables) > 0 {
+		payload["variables"] = variables
+	}
+	if operationName != "" {
+		payload["operationName"] = operationName
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return APIRequest{}, fmt.Errorf("error encoding GraphQL request: %v", err)
+	}
+
+	headers := make(map[string]string, len(req.Headers)+1)
+	for key, value := range req.Headers {
+		headers[key] = value
+	}
+	if _, exists := headers["Accept"]; !exists {
+		headers["Accept"] = "application/json"
+	}
+
+	return APIRequest{
+		URL:         req.URL,
+		Method:      POST,
+		Headers:     headers,
+		Body:        string(body),
+		BodyType:    BodyJSON,
+		Timeout:     req.Timeout,
+		Auth:        req.Auth,
+		Environment: req.Environment,
+	}, nil
+}
+
+// SendGraphQL validates a GraphQL operation against the endpoint's schema and sends it.
+// If the schema can't be introspected the operation is sent without validation
+// and the response's NotValidated says why.
+func (at *APITester) SendGraphQL(req GraphQLRequest) APIResponse {
+	notValidated := ""
+	if !req.SkipValidation {
+		schema, err := at.graphQLSchema(req, false)
+		if err != nil {
+			notValidated = err.Error()
+		} else if errs := validateGraphQL(schema.schema, req.Query); len(errs) > 0 {
+			return APIResponse{
+				StatusCode: 0,
+				Status:     "Error",
+				Headers:    make(map[string][]string),
+				Error:      "Invalid GraphQL query: " + strings.Join(errs, "; "),
+			}
+		}
+	}
+
+	apiReq, err := req.toAPIRequest(req.Query, req.Variables, req.OperationName)
+	if err != nil {
+		return APIResponse{
+			StatusCode: 0,
+			Status:     "Error",
+			Headers:    make(map[string][]string),
+			Error:      fmt.Sprintf("Error creating request: %v", err),
+		}
+	}
+	resp := at.SendRequest(apiReq)
+	resp.NotValidated = notValidated
+	return resp
+}
+
+// IntrospectGraphQL fetches the schema of a GraphQL endpoint, using the cached
+// copy unless refresh is set
+func (at *APITester) IntrospectGraphQL(req GraphQLRequest, refresh bool) (GraphQLSchemaInfo, error) {
+	schema, err := at.graphQLSchema(req, refresh)
+	if err != nil {
+		return GraphQLSchemaInfo{}, err
+	}
+	return schema.info, nil
+}
+
+// ValidateGraphQL checks a query against the endpoint's schema and returns the
+// validation errors, if any
+func (at *APITester) ValidateGraphQL(req GraphQLRequest) ([]string, error) {
+	schema, err := at.graphQLSchema(req, false)
+	if err != nil {
+		return nil, err
+	}
+	return validateGraphQL(schema.schema, req.Query), nil
+}
+
+// validateGraphQL returns the validation errors of query against schema
+func validateGraphQL(schema *ast.Schema, query string) []string {
+	_, errs := gqlparser.LoadQueryWithRules(schema, query, nil)
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
+
+// graphQLSchema returns the cached schema for the request's URL, introspecting
+// it if needed. A failed introspection is not retried for graphQLFailureTTL
+// unless refresh is set, so endpoints that disable introspection aren't sent
+// an introspection query with every operation.
+func (at *APITester) graphQLSchema(req GraphQLRequest, refresh bool) (*graphQLSchema, error) {
+	if !refresh {
+		at.graphql.mutex.Lock()
+		cached, exists := at.graphql.schemas[req.URL]
+		failure, failed := at.graphql.failures[req.URL]
+		at.graphql.mutex.Unlock()
+		if exists {
+			return cached, nil
+		}
+		if failed && time.Since(failure.at) < graphQLFailureTTL {
+			return nil, failure.err
+		}
+	}
+
+	schema, err := at.introspectGraphQL(req)
+
+	at.graphql.mutex.Lock()
+	defer at.graphql.mutex.Unlock()
+	if err != nil {
+		at.graphql.failures[req.URL] = graphQLFailure{err: err, at: time.Now()}
+		return nil, err
+	}
+	delete(at.graphql.failures, req.URL)
+	at.graphql.schemas[req.URL] = schema
+	return schema, nil
+}
+
+// introspectGraphQL fetches and parses the schema of the request's endpoint
+func (at *APITester) introspectGraphQL(req GraphQLRequest) (*graphQLSchema, error) {
+	apiReq, err := req.toAPIRequest(introspectionQuery, nil, "IntrospectionQuery")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := at.SendRequest(apiReq)
+	if resp.Error != "" {
+		return nil, fmt.Errorf("introspection request failed: %s", resp.Error)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("introspection request returned %s", resp.Status)
+	}
+
+	var result introspectionResponse
+	if err := json.Unmarshal([]byte(resp.Body), &result); err != nil {
+		return nil, fmt.Errorf("error parsing introspection result: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("introspection failed: %s", result.Errors[0].Message)
+	}
+	if result.Data.Schema == nil {
+		return nil, fmt.Errorf("introspection result did not include a schema")
+	}
+
+	parsed, err := gqlparser.LoadSchema(&ast.Source{
+		Name:  req.URL,
+		Input: schemaSDL(result.Data.Schema),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error loading introspected schema: %v", err)
+	}
+
+	schema := &graphQLSchema{
+		info: GraphQLSchemaInfo{
+			URL:        req.URL,
+			FetchedAt:  time.Now(),
+			Operations: schemaOperations(result.Data.Schema),
+			Types:      schemaTypeNames(result.Data.Schema),
+		},
+		schema: parsed,
+	}
+	return schema, nil
+}
+
+// GetGraphQLOperations returns the operations of a cached schema
+func (at *APITester) GetGraphQLOperations(url string) ([]GraphQLOperation, error) {
+	at.graphql.mutex.Lock()
+	defer at.graphql.mutex.Unlock()
+
+	schema, exists := at.graphql.schemas[url]
+	if !exists {
+		return nil, fmt.Errorf("no schema cached for %s, introspect it first", url)
+	}
+	return schema.info.Operations, nil
+}
+
+// schemaOperations lists the fields of the query, mutation and subscription root types
+func schemaOperations(schema *introspectionSchema) []GraphQLOperation {
+	roots := []struct {
+		kind string
+		root *struct{ Name string }
+	}{
+		{"query", schema.QueryType},
+		{"mutation", schema.MutationType},
+		{"subscription", schema.SubscriptionType},
+	}
+
+	types := make(map[string]*introspectionType, len(schema.Types))
+	for i := range schema.Types {
+		types[schema.Types[i].Name] = &schema.Types[i]
+	}
+
+	operations := []GraphQLOperation{}
+	for _, r := range roots {
+		if r.root == nil || types[r.root.Name] == nil {
+			continue
+		}
+		for _, field := range types[r.root.Name].Fields {
+			operation := GraphQLOperation{
+				Name:              field.Name,
+				Kind:              r.kind,
+				Description:       field.Description,
+				Arguments:         make([]GraphQLArgument, 0, len(field.Args)),
+				ReturnType:        field.Type.String(),
+				Deprecated:        field.IsDeprecated,
+				DeprecationReason: field.DeprecationReason,
+			}
+			for _, arg := range field.Args {
+				argument := GraphQLArgument{
+					Name:        arg.Name,
+					Type:        arg.Type.String(),
+					Description: arg.Description,
+				}
+				if arg.DefaultValue != nil {
+					argument.DefaultValue = *arg.DefaultValue
+				}
+				operation.Arguments = append(operation.Arguments, argument)
+			}
+			operations = append(operations, operation)
+		}
+	}
+	return operations
+}
+
+// schemaTypeNames returns the sorted names of the user-defined types
+func schemaTypeNames(schema *introspectionSchema) []string {
+	names := []string{}
+	for _, t := range schema.Types {
+		if builtinSchema.Types[t.Name] == nil {
+			names = append(names, t.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String renders a type reference in GraphQL syntax, e.g. [String!]!
+func (t introspectionTypeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		if t.OfType != nil {
+			return t.OfType.String() + "!"
+		}
+	case "LIST":
+		if t.OfType != nil {
+			return "[" + t.OfType.String() + "]"
+		}
+	}
+	return t.Name
+}
+
+// schemaSDL rebuilds a schema definition from an introspection result so that
+// it can be loaded for validation
+func schemaSDL(schema *introspectionSchema) string {
+	var sb strings.Builder
+
+	writeArgs := func(args []introspectionInputValue) {
+		if len(args) == 0 {
+			return
+		}
+		sb.WriteString("(")
+		for i, arg := range args {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			writeInputValue(&sb, arg)
+		}
+		sb.WriteString(")")
+	}
+
+	// Root operation types
+	sb.WriteString("schema {\n")
+	if schema.QueryType != nil {
+		fmt.Fprintf(&sb, "  query: %s\n", schema.QueryType.Name)
+	}
+	if schema.MutationType != nil {
+		fmt.Fprintf(&sb, "  mutation: %s\n", schema.MutationType.Name)
+	}
+	if schema.SubscriptionType != nil {
+		fmt.Fprintf(&sb, "  subscription: %s\n", schema.SubscriptionType.Name)
+	}
+	sb.WriteString("}\n\n")
+
+	for _, t := range schema.Types {
+		if builtinSchema.Types[t.Name] != nil {
+			continue
+		}
+
+		switch t.Kind {
+		case "SCALAR":
+			fmt.Fprintf(&sb, "scalar %s\n\n", t.Name)
+
+		case "OBJECT", "INTERFACE":
+			keyword := "type"
+			if t.Kind == "INTERFACE" {
+				keyword = "interface"
+			}
+			fmt.Fprintf(&sb, "%s %s", keyword, t.Name)
+			if len(t.Interfaces) > 0 {
+				names := make([]string, 0, len(t.Interfaces))
+				for _, iface := range t.Interfaces {
+					names = append(names, iface.Name)
+				}
+				fmt.Fprintf(&sb, " implements %s", strings.Join(names, " & "))
+			}
+			if len(t.Fields) > 0 {
+				sb.WriteString(" {\n")
+				for _, field := range t.Fields {
+					fmt.Fprintf(&sb, "  %s", field.Name)
+					writeArgs(field.Args)
+					fmt.Fprintf(&sb, ": %s\n", field.Type.String())
+				}
+				sb.WriteString("}")
+			}
+			sb.WriteString("\n\n")
+
+		case "UNION":
+			names := make([]string, 0, len(t.PossibleTypes))
+			for _, member := range t.PossibleTypes {
+				names = append(names, member.Name)
+			}
+			fmt.Fprintf(&sb, "union %s = %s\n\n", t.Name, strings.Join(names, " | "))
+
+		case "ENUM":
+			fmt.Fprintf(&sb, "enum %s {\n", t.Name)
+			for _, value := range t.EnumValues {
+				fmt.Fprintf(&sb, "  %s\n", value.Name)
+			}
+			sb.WriteString("}\n\n")
+
+		case "INPUT_OBJECT":
+			fmt.Fprintf(&sb, "input %s {\n", t.Name)
+			for _, field := range t.InputFields {
+				sb.WriteString("  ")
+				writeInputValue(&sb, field)
+				sb.WriteString("\n")
+			}
+			sb.WriteString("}\n\n")
+		}
+	}
+
+	for _, directive := range schema.Directives {
+		if builtinSchema.Directives[directive.Name] != nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "directive @%s", directive.Name)
+		writeArgs(directive.Args)
+		fmt.Fprintf(&sb, " on %s\n\n", strings.Join(directive.Locations, " | "))
+	}
+
+	return sb.String()
+}
+
+// writeInputValue writes an argument or input field definition
+func writeInputValue(sb *strings.Builder, value introspectionInputValue) {
+	fmt.Fprintf(sb, "%s: %s", value.Name, value.Type.String())
+	if value.DefaultValue != nil {
+		fmt.Fprintf(sb, " = %s", *value.DefaultValue)
+	}
+}
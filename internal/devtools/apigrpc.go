@@ -0,0 +1,550 @@
+package devtools
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Reflection service methods. v1alpha is wire-compatible with v1, so the same
+// messages are used for servers that only implement the older version.
+const (
+	reflectionMethodV1      = "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"
+	reflectionMethodV1Alpha = "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"
+)
+
+// GRPCTarget describes a gRPC server and where its service definitions come from.
+// Without ProtoFiles or ProtoRoot the server's reflection service is used.
+type GRPCTarget struct {
+	Address     string            `json:"address"`
+	Plaintext   bool              `json:"plaintext"`
+	SkipVerify  bool              `json:"skipVerify,omitempty"`
+	Headers     map[string]string `json:"headers"` // sent as request metadata
+	Auth        *APIAuth          `json:"auth,omitempty"`
+	Timeout     int               `json:"timeout"` // in seconds
+	ProtoRepoID string            `json:"protoRepoId,omitempty"`
+	ProtoRoot   string            `json:"protoRoot,omitempty"`
+	ProtoFiles  []string          `json:"protoFiles,omitempty"` // relative to ProtoRoot; all .proto files if empty
+}
+
+// GRPCMethod describes a method of a gRPC service
+type GRPCMethod struct {
+	Name            string `json:"name"`
+	FullName        string `json:"fullName"` // e.g. /pkg.Service/Method
+	InputType       string `json:"inputType"`
+	OutputType      string `json:"outputType"`
+	ClientStreaming bool   `json:"clientStreaming"`
+	ServerStreaming bool   `json:"serverStreaming"`
+	RequestTemplate string `json:"requestTemplate"`
+}
+
+// GRPCService describes a gRPC service
+type GRPCService struct {
+	Name    string       `json:"name"`
+	Methods []GRPCMethod `json:"methods"`
+}
+
+// GRPCRequest is a call to a gRPC method with a JSON-encoded request message
+type GRPCRequest struct {
+	Target GRPCTarget `json:"target"`
+	Method string     `json:"method"` // e.g. pkg.Service/Method
+	Body   string     `json:"body"`
+}
+
+// GRPCResponse is the result of a gRPC call. Server-streaming calls return one
+// JSON message per response received.
+type GRPCResponse struct {
+	Messages      []string            `json:"messages"`
+	StatusCode    int                 `json:"statusCode"`
+	StatusName    string              `json:"statusName"`
+	StatusMessage string              `json:"statusMessage,omitempty"`
+	Headers       map[string][]string `json:"headers"`
+	Trailers      map[string][]string `json:"trailers"`
+	Duration      int64               `json:"duration"` // in milliseconds
+	Error         string              `json:"error,omitempty"`
+}
+
+// grpcDescriptors resolves services and message types for a target
+type grpcDescriptors struct {
+	files *protoregistry.Files
+	types *dynamicpb.Types
+}
+
+// dialGRPC connects to the target with the configured transport security
+func dialGRPC(target GRPCTarget) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if !target.Plaintext {
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: target.SkipVerify})
+	}
+
+	conn, err := grpc.NewClient(target.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s: %v", target.Address, err)
+	}
+	return conn, nil
+}
+
+// grpcContext returns a context carrying the target's timeout, headers and auth as metadata
+func (at *APITester) grpcContext(target GRPCTarget) (context.Context, context.CancelFunc, error) {
+	timeout := 30
+	if target.Timeout > 0 {
+		timeout = target.Timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+
+	// Reuse the HTTP auth helpers by applying them to a throwaway request
+	httpReq, _ := http.NewRequest(http.MethodPost, "http://"+target.Address, nil)
+	for key, value := range target.Headers {
+		httpReq.Header.Set(key, value)
+	}
+	if target.Auth != nil && target.Auth.Type == AuthAPIKey && target.Auth.APIKeyIn == "query" {
+		cancel()
+		return nil, nil, fmt.Errorf("gRPC API keys can only be sent as metadata")
+	}
+	if err := at.applyAuth(httpReq, target.Auth); err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("error applying authentication: %v", err)
+	}
+
+	md := metadata.MD{}
+	for key, values := range httpReq.Header {
+		md.Append(strings.ToLower(key), values...)
+	}
+	return metadata.NewOutgoingContext(ctx, md), cancel, nil
+}
+
+// loadGRPCDescriptors loads service definitions from .proto files or server reflection
+func (at *APITester) loadGRPCDescriptors(ctx context.Context, conn *grpc.ClientConn, target GRPCTarget) (*grpcDescriptors, error) {
+	var files *protoregistry.Files
+	var err error
+	if target.ProtoRoot != "" {
+		files, err = compileProtoFiles(ctx, expandHome(target.ProtoRoot), target.ProtoFiles)
+	} else {
+		files, err = reflectDescriptors(ctx, conn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &grpcDescriptors{files: files, types: dynamicpb.NewTypes(files)}, nil
+}
+
+// compileProtoFiles parses .proto files under root. When no files are given,
+// every .proto file under root is compiled.
+func compileProtoFiles(ctx context.Context, root string, protoFiles []string) (*protoregistry.Files, error) {
+	if len(protoFiles) == 0 {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				switch d.Name() {
+				case ".git", "node_modules", "vendor":
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(path, ".proto") {
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				protoFiles = append(protoFiles, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error finding .proto files: %v", err)
+		}
+		if len(protoFiles) == 0 {
+			return nil, fmt.Errorf("no .proto files found in %s", root)
+		}
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: []string{root},
+		}),
+	}
+	compiled, err := compiler.Compile(ctx, protoFiles...)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling .proto files: %v", err)
+	}
+
+	// Register the compiled files together with everything they import
+	files := new(protoregistry.Files)
+	var register func(fd protoreflect.FileDescriptor) error
+	register = func(fd protoreflect.FileDescriptor) error {
+		if _, err := files.FindFileByPath(fd.Path()); err == nil {
+			return nil
+		}
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			if err := register(imports.Get(i).FileDescriptor); err != nil {
+				return err
+			}
+		}
+		return files.RegisterFile(fd)
+	}
+	for _, fd := range compiled {
+		if err := register(fd); err != nil {
+			return nil, fmt.Errorf("error registering %s: %v", fd.Path(), err)
+		}
+	}
+	return files, nil
+}
+
+// reflectDescriptors fetches the file descriptors of every service from the
+// server's reflection service
+func reflectDescriptors(ctx context.Context, conn *grpc.ClientConn) (*protoregistry.Files, error) {
+	client, err := newReflectionClient(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer client.close()
+
+	resp, err := client.request(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make(map[string]*descriptorpb.FileDescriptorProto)
+	var pending []string
+	add := func(fdResp *reflectionpb.FileDescriptorResponse) error {
+		for _, raw := range fdResp.GetFileDescriptorProto() {
+			fd := new(descriptorpb.FileDescriptorProto)
+			if err := proto.Unmarshal(raw, fd); err != nil {
+				return fmt.Errorf("error decoding file descriptor: %v", err)
+			}
+			if _, exists := loaded[fd.GetName()]; exists {
+				continue
+			}
+			loaded[fd.GetName()] = fd
+			pending = append(pending, fd.GetDependency()...)
+		}
+		return nil
+	}
+
+	// Load the file defining each service
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		resp, err := client.request(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service.GetName()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := add(resp.GetFileDescriptorResponse()); err != nil {
+			return nil, err
+		}
+	}
+
+	// Then any dependencies the server didn't send along
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if _, exists := loaded[name]; exists {
+			continue
+		}
+		resp, err := client.request(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := add(resp.GetFileDescriptorResponse()); err != nil {
+			return nil, err
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range loaded {
+		set.File = append(set.File, fd)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("error building descriptors from reflection: %v", err)
+	}
+	return files, nil
+}
+
+// reflectionClient is a bidirectional stream to a server's reflection service
+type reflectionClient struct {
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+// newReflectionClient opens a reflection stream, falling back to v1alpha for older servers
+func newReflectionClient(ctx context.Context, conn *grpc.ClientConn) (*reflectionClient, error) {
+	desc := &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}
+
+	var lastErr error
+	for _, method := range []string{reflectionMethodV1, reflectionMethodV1Alpha} {
+		streamCtx, cancel := context.WithCancel(ctx)
+		stream, err := conn.NewStream(streamCtx, desc, method)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("error opening reflection stream: %v", err)
+		}
+
+		client := &reflectionClient{stream: stream, cancel: cancel}
+
+		// Probe the stream; Unimplemented means this version isn't served
+		_, err = client.request(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+		})
+		if err == nil {
+			return client, nil
+		}
+		client.close()
+		lastErr = err
+		if status.Code(err) != codes.Unimplemented {
+			break
+		}
+	}
+	return nil, fmt.Errorf("server reflection is not available: %v", lastErr)
+}
+
+// request sends one reflection request and waits for its response
+func (rc *reflectionClient) request(req *reflectionpb.ServerReflectionRequest) (*reflectionpb.ServerReflectionResponse, error) {
+	if err := rc.stream.SendMsg(req); err != nil {
+		return nil, rc.streamError(err)
+	}
+
+	resp := new(reflectionpb.ServerReflectionResponse)
+	if err := rc.stream.RecvMsg(resp); err != nil {
+		return nil, rc.streamError(err)
+	}
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return nil, status.Error(codes.Code(errResp.GetErrorCode()), errResp.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+// streamError returns the status error behind a failed send, which gRPC only
+// reports on the next receive
+func (rc *reflectionClient) streamError(err error) error {
+	if err == io.EOF {
+		if recvErr := rc.stream.RecvMsg(new(reflectionpb.ServerReflectionResponse)); recvErr != nil && recvErr != io.EOF {
+			return recvErr
+		}
+	}
+	return err
+}
+
+// close ends the reflection stream
+func (rc *reflectionClient) close() {
+	rc.stream.CloseSend()
+	rc.cancel()
+}
+
+// ListGRPCServices lists the services and methods available on a target
+func (at *APITester) ListGRPCServices(target GRPCTarget) ([]GRPCService, error) {
+	ctx, cancel, err := at.grpcContext(target)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	conn, err := dialGRPC(target)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	descriptors, err := at.loadGRPCDescriptors(ctx, conn, target)
+	if err != nil {
+		return nil, err
+	}
+
+	services := []GRPCService{}
+	descriptors.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			sd := fd.Services().Get(i)
+			if strings.HasPrefix(string(sd.FullName()), "grpc.reflection.") {
+				continue
+			}
+
+			service := GRPCService{Name: string(sd.FullName())}
+			for j := 0; j < sd.Methods().Len(); j++ {
+				service.Methods = append(service.Methods, grpcMethodInfo(sd.Methods().Get(j)))
+			}
+			services = append(services, service)
+		}
+		return true
+	})
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services, nil
+}
+
+// grpcMethodInfo describes a method, including a JSON template of its request
+func grpcMethodInfo(md protoreflect.MethodDescriptor) GRPCMethod {
+	template, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(dynamicpb.NewMessage(md.Input()))
+	if err != nil {
+		template = []byte("{}")
+	}
+
+	return GRPCMethod{
+		Name:            string(md.Name()),
+		FullName:        fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name()),
+		InputType:       string(md.Input().FullName()),
+		OutputType:      string(md.Output().FullName()),
+		ClientStreaming: md.IsStreamingClient(),
+		ServerStreaming: md.IsStreamingServer(),
+		RequestTemplate: string(template),
+	}
+}
+
+// InvokeGRPC calls a unary or server-streaming method with a JSON request
+func (at *APITester) InvokeGRPC(req GRPCRequest) GRPCResponse {
+	response := GRPCResponse{
+		Messages: []string{},
+		Headers:  map[string][]string{},
+		Trailers: map[string][]string{},
+	}
+	fail := func(format string, args ...interface{}) GRPCResponse {
+		response.StatusName = "Error"
+		response.Error = fmt.Sprintf(format, args...)
+		return response
+	}
+
+	ctx, cancel, err := at.grpcContext(req.Target)
+	if err != nil {
+		return fail("Error creating request: %v", err)
+	}
+	defer cancel()
+
+	conn, err := dialGRPC(req.Target)
+	if err != nil {
+		return fail("%v", err)
+	}
+	defer conn.Close()
+
+	descriptors, err := at.loadGRPCDescriptors(ctx, conn, req.Target)
+	if err != nil {
+		return fail("Error loading service definitions: %v", err)
+	}
+
+	// Accept "pkg.Service/Method", "/pkg.Service/Method" or "pkg.Service.Method"
+	name := strings.TrimPrefix(req.Method, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[:i] + "." + name[i+1:]
+	}
+	desc, err := descriptors.files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return fail("Method %s not found: %v", req.Method, err)
+	}
+	method, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return fail("%s is not a method", req.Method)
+	}
+	if method.IsStreamingClient() {
+		return fail("Client-streaming methods are not supported")
+	}
+
+	// Build the request message from JSON
+	input := dynamicpb.NewMessage(method.Input())
+	if strings.TrimSpace(req.Body) != "" {
+		if err := (protojson.UnmarshalOptions{Resolver: descriptors.types}).Unmarshal([]byte(req.Body), input); err != nil {
+			return fail("Error parsing request JSON: %v", err)
+		}
+	}
+
+	marshal := protojson.MarshalOptions{Multiline: true, Indent: "  ", Resolver: descriptors.types}
+	fullMethod := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+
+	startTime := time.Now()
+	var header, trailer metadata.MD
+	if method.IsStreamingServer() {
+		var stream grpc.ClientStream
+		stream, err = conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, fullMethod)
+		if err == nil {
+			err = stream.SendMsg(input)
+		}
+		if err == nil {
+			err = stream.CloseSend()
+		}
+		for err == nil {
+			output := dynamicpb.NewMessage(method.Output())
+			if err = stream.RecvMsg(output); err != nil {
+				break
+			}
+			data, marshalErr := marshal.Marshal(output)
+			if marshalErr != nil {
+				err = marshalErr
+				break
+			}
+			response.Messages = append(response.Messages, string(data))
+		}
+		if err == io.EOF {
+			err = nil
+		}
+		if stream != nil {
+			header, _ = stream.Header()
+			trailer = stream.Trailer()
+		}
+	} else {
+		output := dynamicpb.NewMessage(method.Output())
+		err = conn.Invoke(ctx, fullMethod, input, output, grpc.Header(&header), grpc.Trailer(&trailer))
+		if err == nil {
+			data, marshalErr := marshal.Marshal(output)
+			if marshalErr != nil {
+				err = marshalErr
+			} else {
+				response.Messages = append(response.Messages, string(data))
+			}
+		}
+	}
+	response.Duration = time.Since(startTime).Milliseconds()
+
+	for key, values := range header {
+		response.Headers[key] = values
+	}
+	for key, values := range trailer {
+		response.Trailers[key] = values
+	}
+
+	st := status.Convert(err)
+	response.StatusCode = int(st.Code())
+	response.StatusName = st.Code().String()
+	response.StatusMessage = st.Message()
+	return response
+}
+
+// protoRootForRepo returns the directory .proto files are loaded from when a
+// target refers to a registered Git repository
+func protoRootForRepo(repoPath, protoRoot string) string {
+	if protoRoot == "" {
+		return repoPath
+	}
+	if filepath.IsAbs(protoRoot) || strings.HasPrefix(protoRoot, "~/") {
+		return protoRoot
+	}
+	return filepath.Join(repoPath, protoRoot)
+}
@@ -0,0 +1,261 @@
+package devtools
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// echoProto is the service the test server implements
+const echoProto = `syntax = "proto3";
+package devex.test;
+
+message EchoRequest {
+  string message = 1;
+  int32 count = 2;
+}
+
+message EchoReply {
+  string message = 1;
+  int32 index = 2;
+}
+
+service Echo {
+  rpc Say(EchoRequest) returns (EchoReply);
+  rpc Repeat(EchoRequest) returns (stream EchoReply);
+  rpc Fail(EchoRequest) returns (EchoReply);
+}
+`
+
+// startEchoServer starts a reflection-enabled server for echoProto on a
+// loopback port and returns its address
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+
+	service := echoService(t)
+	input := service.Methods().ByName("Say").Input()
+	output := service.Methods().ByName("Say").Output()
+	reply := func(message string, index int) *dynamicpb.Message {
+		msg := dynamicpb.NewMessage(output)
+		msg.Set(output.Fields().ByName("message"), protoreflect.ValueOfString(message))
+		msg.Set(output.Fields().ByName("index"), protoreflect.ValueOfInt32(int32(index)))
+		return msg
+	}
+	field := func(msg *dynamicpb.Message, name string) protoreflect.Value {
+		return msg.Get(input.Fields().ByName(protoreflect.Name(name)))
+	}
+
+	desc := grpc.ServiceDesc{
+		ServiceName: string(service.FullName()),
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{
+				MethodName: "Say",
+				Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+					req := dynamicpb.NewMessage(input)
+					if err := dec(req); err != nil {
+						return nil, err
+					}
+					// Echo the client's metadata back as a header
+					md, _ := metadata.FromIncomingContext(ctx)
+					grpc.SetHeader(ctx, metadata.Pairs("x-client", strings.Join(md.Get("x-client"), ",")))
+					grpc.SetTrailer(ctx, metadata.Pairs("x-trailer", "said"))
+					return reply(field(req, "message").String(), 0), nil
+				},
+			},
+			{
+				MethodName: "Fail",
+				Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+					grpc.SetTrailer(ctx, metadata.Pairs("x-trailer", "failed"))
+					return nil, status.Error(codes.NotFound, "nothing to echo")
+				},
+			},
+		},
+		Streams: []grpc.StreamDesc{
+			{
+				StreamName:    "Repeat",
+				ServerStreams: true,
+				Handler: func(srv interface{}, stream grpc.ServerStream) error {
+					req := dynamicpb.NewMessage(input)
+					if err := stream.RecvMsg(req); err != nil {
+						return err
+					}
+					for i := 0; i < int(field(req, "count").Int()); i++ {
+						if err := stream.SendMsg(reply(field(req, "message").String(), i)); err != nil {
+							return err
+						}
+					}
+					stream.SetTrailer(metadata.Pairs("x-trailer", "repeated"))
+					return nil
+				},
+			},
+		},
+	}
+
+	server := grpc.NewServer()
+	server.RegisterService(&desc, struct{}{})
+	reflection.Register(server)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String()
+}
+
+// echoService compiles echoProto and registers it globally, where the
+// reflection service looks descriptors up
+func echoService(t *testing.T) protoreflect.ServiceDescriptor {
+	t.Helper()
+
+	if desc, err := protoregistry.GlobalFiles.FindDescriptorByName("devex.test.Echo"); err == nil {
+		return desc.(protoreflect.ServiceDescriptor)
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{"echo.proto": echoProto}),
+		},
+	}
+	files, err := compiler.Compile(context.Background(), "echo.proto")
+	if err != nil {
+		t.Fatalf("compile echo.proto: %v", err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(files[0]); err != nil {
+		t.Fatalf("register echo.proto: %v", err)
+	}
+	return files[0].Services().ByName("Echo")
+}
+
+func TestListGRPCServices(t *testing.T) {
+	target := GRPCTarget{Address: startEchoServer(t), Plaintext: true}
+
+	services, err := NewAPITester().ListGRPCServices(target)
+	if err != nil {
+		t.Fatalf("ListGRPCServices: %v", err)
+	}
+	if len(services) != 1 || services[0].Name != "devex.test.Echo" {
+		t.Fatalf("services = %+v, want only devex.test.Echo", services)
+	}
+
+	methods := map[string]GRPCMethod{}
+	for _, method := range services[0].Methods {
+		methods[method.Name] = method
+	}
+	if len(methods) != 3 {
+		t.Fatalf("methods = %+v, want Say, Repeat and Fail", services[0].Methods)
+	}
+	if say := methods["Say"]; say.FullName != "/devex.test.Echo/Say" || say.ServerStreaming || say.InputType != "devex.test.EchoRequest" {
+		t.Errorf("Say = %+v", say)
+	}
+	if !methods["Repeat"].ServerStreaming {
+		t.Errorf("Repeat is not server-streaming")
+	}
+
+	var template map[string]interface{}
+	if err := json.Unmarshal([]byte(methods["Say"].RequestTemplate), &template); err != nil {
+		t.Fatalf("request template is not JSON: %v", err)
+	}
+	if _, ok := template["message"]; !ok {
+		t.Errorf("request template %v has no message field", template)
+	}
+}
+
+func TestInvokeGRPCUnary(t *testing.T) {
+	target := GRPCTarget{
+		Address:   startEchoServer(t),
+		Plaintext: true,
+		Headers:   map[string]string{"X-Client": "devex"},
+	}
+
+	resp := NewAPITester().InvokeGRPC(GRPCRequest{Target: target, Method: "devex.test.Echo/Say", Body: `{"message": "hello"}`})
+	if resp.Error != "" || resp.StatusCode != int(codes.OK) || resp.StatusName != "OK" {
+		t.Fatalf("status = %d %s %q, error %q", resp.StatusCode, resp.StatusName, resp.StatusMessage, resp.Error)
+	}
+	if len(resp.Messages) != 1 {
+		t.Fatalf("messages = %v, want one", resp.Messages)
+	}
+
+	var reply map[string]interface{}
+	if err := json.Unmarshal([]byte(resp.Messages[0]), &reply); err != nil {
+		t.Fatalf("reply is not JSON: %v", err)
+	}
+	if reply["message"] != "hello" {
+		t.Errorf("reply = %v, want message hello", reply)
+	}
+	if got := resp.Headers["x-client"]; len(got) != 1 || got[0] != "devex" {
+		t.Errorf("x-client header = %v, want the metadata sent", got)
+	}
+	if got := resp.Trailers["x-trailer"]; len(got) != 1 || got[0] != "said" {
+		t.Errorf("x-trailer = %v, want said", got)
+	}
+}
+
+func TestInvokeGRPCServerStreaming(t *testing.T) {
+	target := GRPCTarget{Address: startEchoServer(t), Plaintext: true}
+
+	resp := NewAPITester().InvokeGRPC(GRPCRequest{Target: target, Method: "/devex.test.Echo/Repeat", Body: `{"message": "hi", "count": 3}`})
+	if resp.Error != "" || resp.StatusCode != int(codes.OK) {
+		t.Fatalf("status = %d %s, error %q", resp.StatusCode, resp.StatusName, resp.Error)
+	}
+	if len(resp.Messages) != 3 {
+		t.Fatalf("got %d messages, want 3: %v", len(resp.Messages), resp.Messages)
+	}
+	for i, message := range resp.Messages {
+		var reply struct {
+			Message string `json:"message"`
+			Index   int    `json:"index"`
+		}
+		if err := json.Unmarshal([]byte(message), &reply); err != nil {
+			t.Fatalf("message %d is not JSON: %v", i, err)
+		}
+		if reply.Message != "hi" || reply.Index != i {
+			t.Errorf("message %d = %+v", i, reply)
+		}
+	}
+	if got := resp.Trailers["x-trailer"]; len(got) != 1 || got[0] != "repeated" {
+		t.Errorf("x-trailer = %v, want repeated", got)
+	}
+}
+
+func TestInvokeGRPCErrorStatus(t *testing.T) {
+	target := GRPCTarget{Address: startEchoServer(t), Plaintext: true}
+
+	resp := NewAPITester().InvokeGRPC(GRPCRequest{Target: target, Method: "devex.test.Echo.Fail", Body: `{}`})
+	if resp.Error != "" {
+		t.Fatalf("error = %q, want the status only", resp.Error)
+	}
+	if resp.StatusCode != int(codes.NotFound) || resp.StatusName != "NotFound" || resp.StatusMessage != "nothing to echo" {
+		t.Errorf("status = %d %s %q", resp.StatusCode, resp.StatusName, resp.StatusMessage)
+	}
+	if len(resp.Messages) != 0 {
+		t.Errorf("messages = %v, want none", resp.Messages)
+	}
+	if got := resp.Trailers["x-trailer"]; len(got) != 1 || got[0] != "failed" {
+		t.Errorf("x-trailer = %v, want failed", got)
+	}
+}
+
+func TestInvokeGRPCUnknownMethod(t *testing.T) {
+	target := GRPCTarget{Address: startEchoServer(t), Plaintext: true}
+
+	resp := NewAPITester().InvokeGRPC(GRPCRequest{Target: target, Method: "devex.test.Echo/Missing"})
+	if resp.Error == "" || resp.StatusName != "Error" {
+		t.Errorf("response = %+v, want an error for an unknown method", resp)
+	}
+}
@@ -0,0 +1,478 @@
+package devtools
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// StreamKind represents the protocol of a streaming session
+type StreamKind string
+
+const (
+	StreamWebSocket StreamKind = "websocket"
+	StreamSSE       StreamKind = "sse"
+)
+
+// StreamEvent is the Wails event emitted for every message of a streaming session
+const StreamEvent = "apitester:stream"
+
+const (
+	// maxTranscriptMessages caps how many messages a session keeps
+	maxTranscriptMessages = 10000
+
+	// streamPingInterval is how often WebSocket sessions are pinged to keep them alive
+	streamPingInterval = 30 * time.Second
+)
+
+// StreamConfig describes a streaming session to open
+type StreamConfig struct {
+	Kind         StreamKind        `json:"kind"`
+	URL          string            `json:"url"`
+	Headers      map[string]string `json:"headers"`
+	Subprotocols []string          `json:"subprotocols,omitempty"`
+	Auth         *APIAuth          `json:"auth,omitempty"`
+}
+
+// StreamMessage is one entry of a session transcript
+type StreamMessage struct {
+	SessionID string    `json:"sessionId"`
+	Timestamp time.Time `json:"timestamp"`
+	Direction string    `json:"direction"` // "in", "out" or "system"
+	Type      string    `json:"type"`      // "text", "binary", "event", "open", "close" or "error"
+	Data      string    `json:"data"`      // base64 for binary frames
+	Event     string    `json:"event,omitempty"`
+	EventID   string    `json:"eventId,omitempty"`
+}
+
+// StreamSession describes an open or closed streaming session
+type StreamSession struct {
+	ID           string     `json:"id"`
+	Kind         StreamKind `json:"kind"`
+	URL          string     `json:"url"`
+	Subprotocol  string     `json:"subprotocol,omitempty"`
+	Status       string     `json:"status"` // "open" or "closed"
+	OpenedAt     time.Time  `json:"openedAt"`
+	ClosedAt     time.Time  `json:"closedAt,omitempty"`
+	CloseCode    int        `json:"closeCode,omitempty"`
+	CloseReason  string     `json:"closeReason,omitempty"`
+	MessageCount int        `json:"messageCount"`
+}
+
+// streamSession is the live state behind a StreamSession
+type streamSession struct {
+	info       StreamSession
+	transcript []StreamMessage
+	conn       *websocket.Conn
+	cancel     context.CancelFunc
+	mutex      sync.Mutex
+	writeMutex sync.Mutex
+	emit       EventEmitter
+}
+
+// record appends a message to the transcript and emits it to the frontend
+func (s *streamSession) record(direction, msgType, data, event, eventID string) {
+	msg := StreamMessage{
+		SessionID: s.info.ID,
+		Timestamp: time.Now(),
+		Direction: direction,
+		Type:      msgType,
+		Data:      data,
+		Event:     event,
+		EventID:   eventID,
+	}
+
+	s.mutex.Lock()
+	s.transcript = append(s.transcript, msg)
+	if len(s.transcript) > maxTranscriptMessages {
+		s.transcript = s.transcript[len(s.transcript)-maxTranscriptMessages:]
+	}
+	s.info.MessageCount++
+	s.mutex.Unlock()
+
+	if s.emit != nil {
+		s.emit(StreamEvent, msg)
+	}
+}
+
+// markClosed records that the session ended, unless it already did
+func (s *streamSession) markClosed(code int, reason string) {
+	s.mutex.Lock()
+	if s.info.Status == "closed" {
+		s.mutex.Unlock()
+		return
+	}
+	s.info.Status = "closed"
+	s.info.ClosedAt = time.Now()
+	s.info.CloseCode = code
+	s.info.CloseReason = reason
+	s.mutex.Unlock()
+
+	s.record("system", "close", reason, "", "")
+}
+
+// snapshot returns a copy of the session info
+func (s *streamSession) snapshot() StreamSession {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.info
+}
+
+// SetEventEmitter sets the function used to push events to the frontend
+func (at *APITester) SetEventEmitter(emit EventEmitter) {
+	at.streamMutex.Lock()
+	defer at.streamMutex.Unlock()
+	at.emit = emit
+}
+
+// OpenStream opens a WebSocket or SSE session that runs in the background
+func (at *APITester) OpenStream(config StreamConfig) (StreamSession, error) {
+	// Build the handshake request so headers and auth are applied like any other request
+	httpReq, err := http.NewRequest(http.MethodGet, config.URL, nil)
+	if err != nil {
+		return StreamSession{}, fmt.Errorf("error creating request: %v", err)
+	}
+	for key, value := range config.Headers {
+		httpReq.Header.Set(key, value)
+	}
+	if err := at.applyAuth(httpReq, config.Auth); err != nil {
+		return StreamSession{}, fmt.Errorf("error applying authentication: %v", err)
+	}
+
+	at.streamMutex.Lock()
+	emit := at.emit
+	at.streamMutex.Unlock()
+
+	session := &streamSession{
+		info: StreamSession{
+			ID:       fmt.Sprintf("stream-%d", time.Now().UnixNano()),
+			Kind:     config.Kind,
+			URL:      config.URL,
+			Status:   "open",
+			OpenedAt: time.Now(),
+		},
+		emit: emit,
+	}
+
+	switch config.Kind {
+	case StreamWebSocket:
+		if err := at.openWebSocket(session, httpReq, config.Subprotocols); err != nil {
+			return StreamSession{}, err
+		}
+	case StreamSSE:
+		if err := at.openSSE(session, httpReq); err != nil {
+			return StreamSession{}, err
+		}
+	default:
+		return StreamSession{}, fmt.Errorf("unsupported stream kind: %s", config.Kind)
+	}
+
+	at.streamMutex.Lock()
+	at.streams[session.info.ID] = session
+	at.streamMutex.Unlock()
+
+	return session.snapshot(), nil
+}
+
+// openWebSocket dials a WebSocket and starts its read and keep-alive loops
+func (at *APITester) openWebSocket(session *streamSession, httpReq *http.Request, subprotocols []string) error {
+	// The dialer sets these handshake headers itself and rejects duplicates
+	header := httpReq.Header.Clone()
+	for _, key := range []string{"Upgrade", "Connection", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions", "Sec-Websocket-Protocol"} {
+		header.Del(key)
+	}
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 30 * time.Second,
+		Subprotocols:     subprotocols,
+	}
+
+	conn, resp, err := dialer.Dial(httpReq.URL.String(), header)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("error opening WebSocket: %v (%s)", err, resp.Status)
+		}
+		return fmt.Errorf("error opening WebSocket: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session.conn = conn
+	session.cancel = cancel
+	session.info.Subprotocol = conn.Subprotocol()
+	session.record("system", "open", resp.Status, "", "")
+
+	// Read frames until the connection closes
+	go func() {
+		defer cancel()
+		for {
+			msgType, data, err := conn.ReadMessage()
+			if err != nil {
+				code, reason := websocket.CloseAbnormalClosure, err.Error()
+				if closeErr, ok := err.(*websocket.CloseError); ok {
+					code, reason = closeErr.Code, closeErr.Text
+				}
+				conn.Close()
+				session.markClosed(code, reason)
+				return
+			}
+
+			if msgType == websocket.BinaryMessage {
+				session.record("in", "binary", base64.StdEncoding.EncodeToString(data), "", "")
+			} else {
+				session.record("in", "text", string(data), "", "")
+			}
+		}
+	}()
+
+	// Ping periodically so idle connections aren't dropped by proxies
+	go func() {
+		ticker := time.NewTicker(streamPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				session.writeMutex.Lock()
+				err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
+				session.writeMutex.Unlock()
+				if err != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// openSSE connects to a Server-Sent Events endpoint and starts its read loop
+func (at *APITester) openSSE(session *streamSession, httpReq *http.Request) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	httpReq = httpReq.WithContext(ctx)
+	httpReq.Header.Set("Accept", "text/event-stream")
+	httpReq.Header.Set("Cache-Control", "no-cache")
+
+	// No client timeout: the stream stays open until it is closed
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("error opening event stream: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return fmt.Errorf("event stream returned %s", resp.Status)
+	}
+
+	session.cancel = cancel
+	session.record("system", "open", resp.Status, "", "")
+
+	go func() {
+		defer resp.Body.Close()
+
+		var event, eventID string
+		var data []string
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			// A blank line dispatches the event collected so far
+			if line == "" {
+				if len(data) > 0 {
+					name := event
+					if name == "" {
+						name = "message"
+					}
+					session.record("in", "event", strings.Join(data, "\n"), name, eventID)
+				}
+				event, data = "", nil
+				continue
+			}
+			if strings.HasPrefix(line, ":") {
+				continue
+			}
+
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			case "id":
+				eventID = value
+			}
+		}
+
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			session.markClosed(0, err.Error())
+			return
+		}
+		session.markClosed(0, "stream ended")
+	}()
+
+	return nil
+}
+
+// getStream looks up a session by ID
+func (at *APITester) getStream(sessionID string) (*streamSession, error) {
+	at.streamMutex.Lock()
+	defer at.streamMutex.Unlock()
+
+	session, exists := at.streams[sessionID]
+	if !exists {
+		return nil, fmt.Errorf("stream session with ID %s not found", sessionID)
+	}
+	return session, nil
+}
+
+// SendStreamMessage sends a frame on a WebSocket session. Binary data is passed as base64.
+func (at *APITester) SendStreamMessage(sessionID, data string, binary bool) error {
+	session, err := at.getStream(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.info.Kind != StreamWebSocket {
+		return fmt.Errorf("messages can only be sent on WebSocket sessions")
+	}
+	if session.snapshot().Status != "open" {
+		return fmt.Errorf("stream session is closed")
+	}
+
+	msgType, payload := websocket.TextMessage, []byte(data)
+	if binary {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return fmt.Errorf("binary data must be base64 encoded: %v", err)
+		}
+		msgType, payload = websocket.BinaryMessage, decoded
+	}
+
+	session.writeMutex.Lock()
+	err = session.conn.WriteMessage(msgType, payload)
+	session.writeMutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("error sending message: %v", err)
+	}
+
+	if binary {
+		session.record("out", "binary", data, "", "")
+	} else {
+		session.record("out", "text", data, "", "")
+	}
+	return nil
+}
+
+// CloseStream closes a session. WebSocket sessions send a close frame with code and reason.
+func (at *APITester) CloseStream(sessionID string, code int, reason string) (StreamSession, error) {
+	session, err := at.getStream(sessionID)
+	if err != nil {
+		return StreamSession{}, err
+	}
+	if session.snapshot().Status != "open" {
+		return session.snapshot(), nil
+	}
+
+	if session.conn != nil {
+		if code == 0 {
+			code = websocket.CloseNormalClosure
+		}
+		session.writeMutex.Lock()
+		session.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(5*time.Second))
+		session.writeMutex.Unlock()
+		session.markClosed(code, reason)
+
+		// Give the server a moment to answer the close frame before dropping the connection
+		time.AfterFunc(2*time.Second, func() { session.conn.Close() })
+	} else {
+		session.markClosed(code, reason)
+	}
+	session.cancel()
+
+	return session.snapshot(), nil
+}
+
+// GetStreams returns all sessions, open and closed
+func (at *APITester) GetStreams() []StreamSession {
+	at.streamMutex.Lock()
+	defer at.streamMutex.Unlock()
+
+	sessions := make([]StreamSession, 0, len(at.streams))
+	for _, session := range at.streams {
+		sessions = append(sessions, session.snapshot())
+	}
+	return sessions
+}
+
+// GetStreamTranscript returns the messages of a session
+func (at *APITester) GetStreamTranscript(sessionID string) ([]StreamMessage, error) {
+	session, err := at.getStream(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	return append([]StreamMessage(nil), session.transcript...), nil
+}
+
+// SaveStreamTranscript writes a session and its messages to a JSON file
+func (at *APITester) SaveStreamTranscript(sessionID, path string) error {
+	session, err := at.getStream(sessionID)
+	if err != nil {
+		return err
+	}
+
+	session.mutex.Lock()
+	data, err := json.MarshalIndent(struct {
+		Session  StreamSession   `json:"session"`
+		Messages []StreamMessage `json:"messages"`
+	}{session.info, session.transcript}, "", "  ")
+	session.mutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("error encoding transcript: %v", err)
+	}
+
+	path = expandHome(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating transcript directory: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing transcript: %v", err)
+	}
+	return nil
+}
+
+// RemoveStream closes a session if needed and forgets it
+func (at *APITester) RemoveStream(sessionID string) error {
+	if _, err := at.CloseStream(sessionID, 0, "removed"); err != nil {
+		return err
+	}
+
+	at.streamMutex.Lock()
+	defer at.streamMutex.Unlock()
+	delete(at.streams, sessionID)
+	return nil
+}
+
+// CloseAllStreams closes every open session
+func (at *APITester) CloseAllStreams() {
+	for _, session := range at.GetStreams() {
+		if session.Status == "open" {
+			at.CloseStream(session.ID, websocket.CloseGoingAway, "shutting down")
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package devtools
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	"sync"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
 // RequestMethod represents an HTTP request method
@@ -24,26 +27,57 @@ const (
 
 // APIRequest represents an API request
 type APIRequest struct {
-	URL     string            `json:"url"`
-	Method  RequestMethod     `json:"method"`
-	Headers map[string]string `json:"headers"`
-	Body    string            `json:"body"`
-	Timeout int               `json:"timeout"` // in seconds
+	URL          string            `json:"url"`
+	Method       RequestMethod     `json:"method"`
+	Headers      map[string]string `json:"headers"`
+	Body         string            `json:"body"`
+	BodyType     BodyType          `json:"bodyType,omitempty"`
+	FormFields   []APIFormField    `json:"formFields,omitempty"`
+	FilePath     string            `json:"filePath,omitempty"` // file sent as a binary body
+	Timeout      int               `json:"timeout"`            // in seconds
+	Auth         *APIAuth          `json:"auth,omitempty"`
+	Environment  string            `json:"environment,omitempty"`  // selects the cookie jar
+	NoRedirects  bool              `json:"noRedirects,omitempty"`  // return 3xx responses instead of following them
+	ResponseFile string            `json:"responseFile,omitempty"` // save the response body here
 }
 
 // APIResponse represents an API response
 type APIResponse struct {
-	StatusCode int               `json:"statusCode"`
-	Status     string            `json:"status"`
-	Headers    map[string]string `json:"headers"`
-	Body       string            `json:"body"`
-	Duration   int64             `json:"duration"` // in milliseconds
-	Error      string            `json:"error,omitempty"`
+	StatusCode   int                 `json:"statusCode"`
+	Status       string              `json:"status"`
+	Headers      map[string][]string `json:"headers"`
+	Cookies      []APICookie         `json:"cookies,omitempty"`
+	Redirects    []APIRedirect       `json:"redirects,omitempty"`
+	Body         string              `json:"body"`
+	BodyBase64   string              `json:"bodyBase64,omitempty"` // set instead of Body for binary content
+	SavedPath    string              `json:"savedPath,omitempty"`  // set when the body was written to a file
+	ContentType  string              `json:"contentType,omitempty"`
+	Size         int64               `json:"size"`
+	IsBinary     bool                `json:"isBinary"`
+	Duration     int64               `json:"duration"` // in milliseconds
+	Timing       *APITiming          `json:"timing,omitempty"`
+	Protocol     string              `json:"protocol,omitempty"`
+	TLS          *APITLSInfo         `json:"tls,omitempty"`
+	Error        string              `json:"error,omitempty"`
+	NotValidated string              `json:"notValidated,omitempty"` // why a GraphQL operation was sent without validation
 }
 
 // APITester provides functionality to test API endpoints
 type APITester struct {
-	client *http.Client
+	client       *http.Client
+	secrets      *SecretStore
+	tokens       map[string]*oauth2Token
+	tokenMutex   sync.Mutex
+	tokenFetches singleflight.Group
+	jars         map[string]*persistentJar
+	cookieDir    string
+	jarMutex     sync.Mutex
+	graphql      graphQLCache
+	streams      map[string]*streamSession
+	streamMutex  sync.Mutex
+	emit         EventEmitter
+	benchmarks   map[string]*benchmarkRun
+	benchMutex   sync.Mutex
 }
 
 // NewAPITester creates a new APITester
@@ -52,6 +86,13 @@ func NewAPITester() *APITester {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		secrets:    GetSecretStore(),
+		tokens:     make(map[string]*oauth2Token),
+		jars:       make(map[string]*persistentJar),
+		cookieDir:  cookieDir(),
+		graphql:    graphQLCache{schemas: make(map[string]*graphQLSchema), failures: make(map[string]graphQLFailure)},
+		streams:    make(map[string]*streamSession),
+		benchmarks: make(map[string]*benchmarkRun),
 	}
 }
 
@@ -63,33 +104,30 @@ func (at *APITester) SendRequest(req APIRequest) APIResponse {
 		timeout = req.Timeout
 	}
 
-	// Create HTTP client with the specified timeout
+	// Create HTTP client with the specified timeout, recording redirects as they happen
+	var redirects []APIRedirect
 	client := &http.Client{
-		Timeout: time.Duration(timeout) * time.Second,
+		Timeout:       time.Duration(timeout) * time.Second,
+		Jar:           at.cookieJar(req.Environment),
+		CheckRedirect: redirectRecorder(&redirects, !req.NoRedirects),
 	}
 
 	// Create HTTP request
-	httpReq, err := http.NewRequest(string(req.Method), req.URL, bytes.NewBufferString(req.Body))
+	httpReq, err := at.buildHTTPRequest(req)
 	if err != nil {
 		return APIResponse{
 			StatusCode: 0,
 			Status:     "Error",
-			Headers:    make(map[string]string),
+			Headers:    make(map[string][]string),
 			Body:       "",
 			Duration:   0,
 			Error:      fmt.Sprintf("Error creating request: %v", err),
 		}
 	}
 
-	// Set headers
-	for key, value := range req.Headers {
-		httpReq.Header.Set(key, value)
-	}
-
-	// Set default Content-Type if not specified and body is not empty
-	if req.Body != "" && httpReq.Header.Get("Content-Type") == "" {
-		httpReq.Header.Set("Content-Type", "application/json")
-	}
+	// Trace the request so the response can report a phase breakdown
+	trace := newRequestTrace()
+	httpReq = httpReq.WithContext(httptrace.WithClientTrace(httpReq.Context(), trace.clientTrace()))
 
 	// Send request and measure duration
 	startTime := time.Now()
@@ -100,9 +138,11 @@ func (at *APITester) SendRequest(req APIRequest) APIResponse {
 		return APIResponse{
 			StatusCode: 0,
 			Status:     "Error",
-			Headers:    make(map[string]string),
+			Headers:    make(map[string][]string),
 			Body:       "",
+			Redirects:  redirects,
 			Duration:   duration,
+			Timing:     trace.timing(time.Now()),
 			Error:      fmt.Sprintf("Error sending request: %v", err),
 		}
 	}
@@ -110,53 +150,81 @@ func (at *APITester) SendRequest(req APIRequest) APIResponse {
 
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
+	timing := trace.timing(time.Now())
 	if err != nil {
 		return APIResponse{
 			StatusCode: resp.StatusCode,
 			Status:     resp.Status,
 			Headers:    convertHeaders(resp.Header),
+			Cookies:    convertCookies(resp.Cookies()),
+			Redirects:  redirects,
 			Body:       "",
 			Duration:   duration,
+			Timing:     timing,
+			Protocol:   resp.Proto,
+			TLS:        tlsInfo(resp.TLS),
 			Error:      fmt.Sprintf("Error reading response body: %v", err),
 		}
 	}
 
-	// Format JSON response if possible
-	var formattedBody string
-	if isJSON(string(body)) {
-		var jsonObj interface{}
-		if err := json.Unmarshal(body, &jsonObj); err == nil {
-			formattedJSON, err := json.MarshalIndent(jsonObj, "", "  ")
-			if err == nil {
-				formattedBody = string(formattedJSON)
-			} else {
-				formattedBody = string(body)
-			}
-		} else {
-			formattedBody = string(body)
-		}
-	} else {
-		formattedBody = string(body)
-	}
-
-	// Return response
-	return APIResponse{
+	response := APIResponse{
 		StatusCode: resp.StatusCode,
 		Status:     resp.Status,
 		Headers:    convertHeaders(resp.Header),
-		Body:       formattedBody,
+		Cookies:    convertCookies(resp.Cookies()),
+		Redirects:  redirects,
 		Duration:   duration,
+		Timing:     timing,
+		Protocol:   resp.Proto,
+		TLS:        tlsInfo(resp.TLS),
 		Error:      "",
 	}
+
+	// Decode the body as text or binary depending on its content
+	if err := applyResponseBody(&response, resp, body, req.ResponseFile); err != nil {
+		response.Error = fmt.Sprintf("Error handling response body: %v", err)
+	}
+
+	// Return response
+	return response
 }
 
-// convertHeaders converts http.Header to map[string]string
-func convertHeaders(headers http.Header) map[string]string {
-	result := make(map[string]string)
+// buildHTTPRequest creates the HTTP request described by req, including its
+// body, headers and authentication
+func (at *APITester) buildHTTPRequest(req APIRequest) (*http.Request, error) {
+	body, contentType, err := buildRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest(string(req.Method), req.URL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set headers
+	for key, value := range req.Headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	// Set default Content-Type if not specified
+	if contentType != "" && httpReq.Header.Get("Content-Type") == "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+
+	// Apply authentication
+	if err := at.applyAuth(httpReq, req.Auth); err != nil {
+		return nil, fmt.Errorf("error applying authentication: %v", err)
+	}
+
+	return httpReq, nil
+}
+
+// convertHeaders converts http.Header to a plain map, keeping every value of repeated headers
+func convertHeaders(headers http.Header) map[string][]string {
+	result := make(map[string][]string, len(headers))
 	for key, values := range headers {
-		if len(values) > 0 {
-			result[key] = values[0]
-		}
+		result[key] = append([]string(nil), values...)
 	}
 	return result
 }
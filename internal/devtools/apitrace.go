@@ -0,0 +1,158 @@
+package devtools
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// APITiming breaks a request down into its network phases (all in milliseconds).
+// When redirects are followed, the phases describe the final hop.
+type APITiming struct {
+	DNSLookup        float64 `json:"dnsLookup"`
+	TCPConnect       float64 `json:"tcpConnect"`
+	TLSHandshake     float64 `json:"tlsHandshake"`
+	TimeToFirstByte  float64 `json:"timeToFirstByte"`
+	ContentTransfer  float64 `json:"contentTransfer"`
+	Total            float64 `json:"total"`
+	ConnectionReused bool    `json:"connectionReused"`
+	RemoteAddr       string  `json:"remoteAddr,omitempty"`
+}
+
+// APICertificate describes a certificate presented by the server
+type APICertificate struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	DNSNames  []string  `json:"dnsNames,omitempty"`
+}
+
+// APITLSInfo describes the negotiated TLS connection
+type APITLSInfo struct {
+	Version      string           `json:"version"`
+	CipherSuite  string           `json:"cipherSuite"`
+	ServerName   string           `json:"serverName,omitempty"`
+	ALPN         string           `json:"alpn,omitempty"`
+	Resumed      bool             `json:"resumed"`
+	Certificates []APICertificate `json:"certificates"`
+}
+
+// requestTrace records the timestamps reported by httptrace
+type requestTrace struct {
+	mutex        sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	reused       bool
+	remoteAddr   string
+}
+
+// newRequestTrace creates a trace whose timestamps are relative to now
+func newRequestTrace() *requestTrace {
+	return &requestTrace{start: time.Now()}
+}
+
+// clientTrace returns the httptrace hooks that populate rt
+func (rt *requestTrace) clientTrace() *httptrace.ClientTrace {
+	record := func(target *time.Time) {
+		rt.mutex.Lock()
+		*target = time.Now()
+		rt.mutex.Unlock()
+	}
+
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			// A new hop (e.g. after a redirect) starts; forget the previous one
+			rt.mutex.Lock()
+			rt.start = time.Now()
+			rt.dnsStart, rt.dnsDone = time.Time{}, time.Time{}
+			rt.connectStart, rt.connectDone = time.Time{}, time.Time{}
+			rt.tlsStart, rt.tlsDone = time.Time{}, time.Time{}
+			rt.wroteRequest, rt.firstByte = time.Time{}, time.Time{}
+			rt.mutex.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			rt.mutex.Lock()
+			rt.reused = info.Reused
+			if info.Conn != nil {
+				rt.remoteAddr = info.Conn.RemoteAddr().String()
+			}
+			rt.mutex.Unlock()
+		},
+		DNSStart:             func(httptrace.DNSStartInfo) { record(&rt.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { record(&rt.dnsDone) },
+		ConnectStart:         func(string, string) { record(&rt.connectStart) },
+		ConnectDone:          func(string, string, error) { record(&rt.connectDone) },
+		TLSHandshakeStart:    func() { record(&rt.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { record(&rt.tlsDone) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { record(&rt.wroteRequest) },
+		GotFirstResponseByte: func() { record(&rt.firstByte) },
+	}
+}
+
+// timing computes the phase durations, treating end as the moment the body was fully read
+func (rt *requestTrace) timing(end time.Time) *APITiming {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	timing := &APITiming{
+		DNSLookup:        millisBetween(rt.dnsStart, rt.dnsDone),
+		TCPConnect:       millisBetween(rt.connectStart, rt.connectDone),
+		TLSHandshake:     millisBetween(rt.tlsStart, rt.tlsDone),
+		TimeToFirstByte:  millisBetween(rt.wroteRequest, rt.firstByte),
+		ContentTransfer:  millisBetween(rt.firstByte, end),
+		Total:            millisBetween(rt.start, end),
+		ConnectionReused: rt.reused,
+		RemoteAddr:       rt.remoteAddr,
+	}
+	return timing
+}
+
+// millisBetween returns the duration between two timestamps in milliseconds,
+// or 0 if either timestamp was never recorded
+func millisBetween(from, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	return float64(to.Sub(from).Microseconds()) / 1000
+}
+
+// tlsInfo converts the negotiated TLS state of a response
+func tlsInfo(state *tls.ConnectionState) *APITLSInfo {
+	if state == nil {
+		return nil
+	}
+
+	info := &APITLSInfo{
+		Version:      tls.VersionName(state.Version),
+		CipherSuite:  tls.CipherSuiteName(state.CipherSuite),
+		ServerName:   state.ServerName,
+		ALPN:         state.NegotiatedProtocol,
+		Resumed:      state.DidResume,
+		Certificates: make([]APICertificate, 0, len(state.PeerCertificates)),
+	}
+	for _, cert := range state.PeerCertificates {
+		info.Certificates = append(info.Certificates, certificateInfo(cert))
+	}
+	return info
+}
+
+// certificateInfo extracts the fields shown for a peer certificate
+func certificateInfo(cert *x509.Certificate) APICertificate {
+	return APICertificate{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+		DNSNames:  cert.DNSNames,
+	}
+}
@@ -0,0 +1,242 @@
+package devtools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// batchConcurrency is how many repositories a batch operation works on at the same time
+const batchConcurrency = 6
+
+// batchTimeout bounds the work done in a single repository by a batch operation
+const batchTimeout = 5 * time.Minute
+
+// maxBatchOutput is how much of the end of stdout and stderr a batch result keeps
+const maxBatchOutput = 4096
+
+// commandWaitDelay is how long a finished or killed command may keep its output
+// open, e.g. through a background job, before it is abandoned
+const commandWaitDelay = 5 * time.Second
+
+// GitBatchResult is the outcome of a batch operation in one repository
+type GitBatchResult struct {
+	RepoID     string `json:"repoId"`
+	RepoName   string `json:"repoName"`
+	Success    bool   `json:"success"`
+	Skipped    bool   `json:"skipped"` // the operation didn't apply, e.g. the branch doesn't exist there
+	ExitCode   int    `json:"exitCode"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// BatchFetch fetches all remotes of each repository
+func (gm *GitRepoManager) BatchFetch(repoIDs []string) []GitBatchResult {
+	return gm.runBatch(repoIDs, func(ctx context.Context, path string, result *GitBatchResult) {
+		runBatchCommand(ctx, path, result, "git", "fetch", "--all", "--prune")
+	})
+}
+
+// BatchPull fast-forwards each repository to its upstream. Repositories that
+// have diverged are left untouched.
+func (gm *GitRepoManager) BatchPull(repoIDs []string) []GitBatchResult {
+	return gm.runBatch(repoIDs, func(ctx context.Context, path string, result *GitBatchResult) {
+		if _, err := runGitContext(ctx, path, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err != nil {
+			result.Skipped = true
+			result.Error = "the current branch has no upstream"
+			return
+		}
+		runBatchCommand(ctx, path, result, "git", "pull", "--ff-only")
+	})
+}
+
+// BatchCheckout switches each repository that has the branch, locally or on a
+// remote, to it. Repositories without the branch are skipped and those with
+// uncommitted changes are refused.
+func (gm *GitRepoManager) BatchCheckout(repoIDs []string, branch string) []GitBatchResult {
+	if branch == "" || strings.HasPrefix(branch, "-") {
+		err := &GitError{Op: "switch", Code: GitErrInvalidRef, Message: fmt.Sprintf("invalid branch name: %s", branch)}
+		return gm.runBatch(repoIDs, func(ctx context.Context, path string, result *GitBatchResult) {
+			result.Error = err.Error()
+		})
+	}
+
+	return gm.runBatch(repoIDs, func(ctx context.Context, path string, result *GitBatchResult) {
+		args := []string{"switch", branch}
+		if _, err := runGitContext(ctx, path, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+			// Fall back to a remote branch of the same name
+			output, err := runGitContext(ctx, path, "for-each-ref", "--format=%(refname:short)", "refs/remotes/*/"+branch)
+			remotes := strings.Fields(output)
+			if err != nil || len(remotes) == 0 {
+				result.Skipped = true
+				result.Error = fmt.Sprintf("branch %s not found", branch)
+				return
+			}
+			args = []string{"switch", "-c", branch, "--track", remotes[0]}
+		}
+
+		if err := checkCleanTree(path, "switch"); err != nil {
+			result.Error = err.Error()
+			return
+		}
+		runBatchCommand(ctx, path, result, "git", args...)
+	})
+}
+
+// BatchRunCommand runs a shell command in each repository
+func (gm *GitRepoManager) BatchRunCommand(repoIDs []string, command string) []GitBatchResult {
+	if strings.TrimSpace(command) == "" {
+		return gm.runBatch(repoIDs, func(ctx context.Context, path string, result *GitBatchResult) {
+			result.Error = "command is empty"
+		})
+	}
+
+	return gm.runBatch(repoIDs, func(ctx context.Context, path string, result *GitBatchResult) {
+		switch runtime.GOOS {
+		case "windows":
+			runBatchCommand(ctx, path, result, "cmd", "/C", command)
+		default: // macOS and Linux
+			runBatchCommand(ctx, path, result, "sh", "-c", command)
+		}
+	})
+}
+
+// runBatch runs an operation in each repository, batchConcurrency at a time,
+// and refreshes the repositories afterwards. Results are in the order of
+// repoIDs.
+func (gm *GitRepoManager) runBatch(repoIDs []string, operation func(ctx context.Context, path string, result *GitBatchResult)) []GitBatchResult {
+	results := make([]GitBatchResult, len(repoIDs))
+	slots := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	seen := make(map[string]bool, len(repoIDs))
+
+	for i, repoID := range repoIDs {
+		// Running twice in the same repository would race with itself
+		if seen[repoID] {
+			results[i] = GitBatchResult{RepoID: repoID, Skipped: true, Error: "repository is listed more than once"}
+			continue
+		}
+		seen[repoID] = true
+
+		wg.Add(1)
+		go func(result *GitBatchResult, repoID string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			result.RepoID = repoID
+			gm.mutex.Lock()
+			repo, exists := gm.repos[repoID]
+			if exists {
+				result.RepoName = repo.Name
+			}
+			gm.mutex.Unlock()
+			if !exists {
+				result.Error = fmt.Sprintf("repository with ID %s not found", repoID)
+				return
+			}
+
+			path := expandHome(repo.Path)
+			if _, err := os.Stat(path); err != nil {
+				result.Error = fmt.Sprintf("repository path %s not found", path)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), batchTimeout)
+			defer cancel()
+			start := time.Now()
+			operation(ctx, path, result)
+			result.DurationMs = time.Since(start).Milliseconds()
+
+			// Even a failed command may have changed the repository
+			gm.RefreshRepo(repoID)
+		}(&results[i], repoID)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// runBatchCommand runs a command in a repository and records its exit code and
+// the tail of its output. Git never prompts for credentials. The command runs
+// in its own process group so that a timeout also stops the processes it started.
+func runBatchCommand(ctx context.Context, path string, result *GitBatchResult, name string, args ...string) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = path
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.WaitDelay = commandWaitDelay
+	setProcessGroup(cmd)
+	stdout := &tailWriter{limit: 2 * maxBatchOutput}
+	stderr := &tailWriter{limit: 2 * maxBatchOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil, errors.Is(err, exec.ErrWaitDelay):
+		// ErrWaitDelay means the command succeeded but left its output open
+		result.Success = true
+	case ctx.Err() == context.DeadlineExceeded:
+		result.ExitCode = -1
+		result.Error = fmt.Sprintf("timed out after %s", batchTimeout)
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+		result.Error = fmt.Sprintf("exited with code %d", result.ExitCode)
+	default:
+		result.ExitCode = -1
+		result.Error = err.Error()
+	}
+}
+
+// tailWriter keeps the last limit bytes written to it, so that a noisy
+// command can't exhaust memory
+type tailWriter struct {
+	buffer  []byte
+	limit   int
+	dropped bool // earlier output was discarded
+}
+
+// Write appends p, discarding the oldest output beyond the limit
+func (w *tailWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(p) > w.limit {
+		p = p[len(p)-w.limit:]
+		w.dropped = true
+	}
+	if excess := len(w.buffer) + len(p) - w.limit; excess > 0 {
+		w.buffer = append(w.buffer[:0], w.buffer[excess:]...)
+		w.dropped = true
+	}
+	w.buffer = append(w.buffer, p...)
+	return n, nil
+}
+
+// String returns the last maxBatchOutput bytes of the output, starting with
+// an ellipsis when anything before them was left out
+func (w *tailWriter) String() string {
+	output := strings.TrimRight(string(w.buffer), "\n")
+	if len(output) <= maxBatchOutput && !w.dropped {
+		return output
+	}
+	start := 0
+	if len(output) > maxBatchOutput {
+		start = len(output) - maxBatchOutput
+	}
+	for start < len(output) && !utf8.RuneStart(output[start]) {
+		start++
+	}
+	return "…" + output[start:]
+}
@@ -0,0 +1,110 @@
+package devtools
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestBatchRunCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	gm := newTestRepoManager(t)
+	first := addTestRepo(t, gm, initTestRepo(t))
+	second := addTestRepo(t, gm, initTestRepo(t))
+
+	results := gm.BatchRunCommand([]string{first, second, "missing"}, "git rev-parse --abbrev-ref HEAD; echo oops >&2; exit 3")
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	for _, result := range results[:2] {
+		if result.Success || result.ExitCode != 3 || result.Stdout != "main" || result.Stderr != "oops" {
+			t.Errorf("result = %+v", result)
+		}
+	}
+	if results[0].RepoID != first || results[1].RepoID != second {
+		t.Errorf("results are not in the order of the repository IDs: %+v", results)
+	}
+	if results[2].Error == "" || results[2].Success {
+		t.Errorf("result for an unknown repository = %+v", results[2])
+	}
+}
+
+func TestBatchRejectsDuplicateRepos(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	gm := newTestRepoManager(t)
+	repo := initTestRepo(t)
+	repoID := addTestRepo(t, gm, repo)
+
+	results := gm.BatchRunCommand([]string{repoID, repoID}, "echo run >> runs.txt")
+	if !results[0].Success {
+		t.Errorf("first result = %+v", results[0])
+	}
+	if !results[1].Skipped || results[1].Error == "" {
+		t.Errorf("duplicate result = %+v, want it skipped", results[1])
+	}
+	if runs := readTestFile(t, repo, "runs.txt"); runs != "run\n" {
+		t.Errorf("runs.txt = %q, want a single run", runs)
+	}
+}
+
+func TestRunBatchCommandKillsBackgroundJobs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// The background sleep keeps stdout open after sh is gone
+	var result GitBatchResult
+	start := time.Now()
+	runBatchCommand(ctx, t.TempDir(), &result, "sh", "-c", "echo started; sleep 30 & sleep 30")
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("command returned after %s, want soon after the timeout", elapsed)
+	}
+	if result.Success || result.ExitCode != -1 || !strings.HasPrefix(result.Error, "timed out") {
+		t.Errorf("result = %+v, want a timeout", result)
+	}
+	if result.Stdout != "started" {
+		t.Errorf("stdout = %q, want the output before the timeout", result.Stdout)
+	}
+}
+
+func TestTailWriter(t *testing.T) {
+	w := &tailWriter{limit: 2 * maxBatchOutput}
+	w.Write([]byte("short\n\n"))
+	if got := w.String(); got != "short" {
+		t.Errorf("short output = %q", got)
+	}
+
+	for i := 0; i < 1000; i++ {
+		w.Write([]byte(strings.Repeat("é", 50) + "\n"))
+	}
+	w.Write([]byte("end\n"))
+	if len(w.buffer) > w.limit {
+		t.Errorf("kept %d bytes, want at most %d", len(w.buffer), w.limit)
+	}
+	got := w.String()
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "\nend") {
+		t.Errorf("tail = %q, want an ellipsis and the end of the output", got)
+	}
+	if len(got) > maxBatchOutput+len("…") {
+		t.Errorf("tail is %d bytes, want at most %d", len(got), maxBatchOutput)
+	}
+	if !utf8.ValidString(got) {
+		t.Errorf("tail starts inside a character: %q", got[:8])
+	}
+
+	// A single write larger than the limit keeps only its end
+	w = &tailWriter{limit: 2 * maxBatchOutput}
+	w.Write([]byte(strings.Repeat("x", 5*maxBatchOutput) + "tail"))
+	if got := w.String(); !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "tail") || len(got) != maxBatchOutput+len("…") {
+		t.Errorf("tail of a large write has %d bytes", len(got))
+	}
+}
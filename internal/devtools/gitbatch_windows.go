@@ -0,0 +1,9 @@
+//go:build windows
+
+package devtools
+
+import "os/exec"
+
+// setProcessGroup leaves cmd as is. Only the command itself is killed when it
+// is cancelled, and WaitDelay stops waiting for its children's output.
+func setProcessGroup(cmd *exec.Cmd) {}
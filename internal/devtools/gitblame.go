@@ -0,0 +1,184 @@
+package devtools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxFileHistory caps the commits GetFileHistory returns
+const maxFileHistory = 1000
+
+// GitBlameLine is a line of a file with the commit that last changed it
+type GitBlameLine struct {
+	LineNumber  int       `json:"lineNumber"`
+	Content     string    `json:"content"`
+	Hash        string    `json:"hash"`
+	ShortHash   string    `json:"shortHash"`
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"authorEmail"`
+	Date        time.Time `json:"date"`
+	Summary     string    `json:"summary"`
+	OrigPath    string    `json:"origPath"` // path of the file in that commit
+	OrigLine    int       `json:"origLine"`
+	Uncommitted bool      `json:"uncommitted"`
+}
+
+// GitBlame is the blame of a file at a revision
+type GitBlame struct {
+	Path  string         `json:"path"`
+	Rev   string         `json:"rev,omitempty"`
+	Lines []GitBlameLine `json:"lines"`
+}
+
+// GitFileRevision is a commit that changed a file, with the file's path in that commit
+type GitFileRevision struct {
+	GitCommit
+	Path    string `json:"path"`
+	OldPath string `json:"oldPath,omitempty"` // set when the commit renamed the file
+	Status  string `json:"status"`
+}
+
+// GetBlame returns the commit that last changed each line of a file at rev,
+// or in the working tree when rev is empty
+func (gm *GitRepoManager) GetBlame(repoID, file, rev string) (GitBlame, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return GitBlame{}, err
+	}
+	if file == "" {
+		return GitBlame{}, &GitError{Op: "blame", Code: GitErrNotFound, Message: "no file selected"}
+	}
+	if strings.HasPrefix(rev, "-") {
+		return GitBlame{}, &GitError{Op: "blame", Code: GitErrInvalidRef, Message: fmt.Sprintf("invalid revision: %s", rev)}
+	}
+
+	args := []string{"blame", "--porcelain"}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	output, err := runGit(path, append(args, "--", file)...)
+	if err != nil {
+		return GitBlame{}, err
+	}
+
+	return GitBlame{Path: file, Rev: rev, Lines: parseBlame(output)}, nil
+}
+
+// parseBlame parses the output of git blame --porcelain. Commit details are
+// only printed the first time a commit appears, so they are remembered by hash.
+func parseBlame(output string) []GitBlameLine {
+	lines := []GitBlameLine{}
+	commits := make(map[string]*GitBlameLine)
+	var current *GitBlameLine
+
+	for _, line := range strings.Split(output, "\n") {
+		// The content of a line ends its entry
+		if strings.HasPrefix(line, "\t") {
+			if current != nil {
+				current.Content = line[1:]
+				lines = append(lines, *current)
+				current = nil
+			}
+			continue
+		}
+
+		if current == nil {
+			// Header: <hash> <orig line> <final line> [<lines in group>]
+			fields := strings.Fields(line)
+			if len(fields) < 3 || !isObjectHash(fields[0]) {
+				continue
+			}
+			entry := GitBlameLine{Hash: fields[0], ShortHash: fields[0][:7]}
+			if known, exists := commits[entry.Hash]; exists {
+				entry = *known
+			} else {
+				// Lines that aren't committed yet are blamed on the all-zero hash
+				entry.Uncommitted = strings.Trim(entry.Hash, "0") == ""
+				commits[entry.Hash] = &entry
+			}
+			entry.OrigLine, _ = strconv.Atoi(fields[1])
+			entry.LineNumber, _ = strconv.Atoi(fields[2])
+			current = &entry
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-mail":
+			current.AuthorEmail = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+		case "author-time":
+			if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				current.Date = time.Unix(seconds, 0)
+			}
+		case "summary":
+			current.Summary = value
+		case "filename":
+			current.OrigPath = value
+		default:
+			continue
+		}
+
+		// Remember details for later lines of the same commit
+		known := commits[current.Hash]
+		known.Author, known.AuthorEmail, known.Date = current.Author, current.AuthorEmail, current.Date
+		known.Summary, known.OrigPath = current.Summary, current.OrigPath
+	}
+	return lines
+}
+
+// GetFileHistory returns the commits that changed a file, newest first,
+// following it across renames
+func (gm *GitRepoManager) GetFileHistory(repoID, file string) ([]GitFileRevision, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return nil, err
+	}
+	if file == "" {
+		return nil, &GitError{Op: "log", Code: GitErrNotFound, Message: "no file selected"}
+	}
+
+	// %x1d separates each commit's fields from its name-status output
+	output, err := runGit(path, "log", "--follow", "-M", "--name-status", "-z",
+		gitCommitFormat+"%x1d", fmt.Sprintf("--max-count=%d", maxFileHistory), "--", file)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []GitFileRevision{}
+	for _, record := range strings.Split(output, "\x1e") {
+		fields, changes, found := strings.Cut(record, "\x1d")
+		if !found {
+			continue
+		}
+		commits := parseCommits("\x1e" + fields)
+		if len(commits) == 0 {
+			continue
+		}
+
+		revision := GitFileRevision{GitCommit: commits[0], Path: file}
+		if stats := parseNameStatus(strings.TrimLeft(changes, "\n\x00")); len(stats) > 0 {
+			revision.Path = stats[0].Path
+			revision.OldPath = stats[0].OldPath
+			revision.Status = stats[0].Status
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
+
+// isObjectHash reports whether s is a full SHA-1 or SHA-256 object name
+func isObjectHash(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
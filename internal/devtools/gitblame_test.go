@@ -0,0 +1,49 @@
+package devtools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testBlame blames a file with a committed line, a line from a later commit
+// and an uncommitted line in a repository with the given object format
+func testBlame(t *testing.T, objectFormat string, hashLength int) {
+	gm := newTestRepoManager(t)
+	dir := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("create repository directory: %v", err)
+	}
+	gitTest(t, dir, "init", "-q", "-b", "main", "--object-format="+objectFormat)
+	commitTestFile(t, dir, "notes.txt", "first\n", "Add notes")
+	commitTestFile(t, dir, "notes.txt", "first\nsecond\n", "Add a second line")
+	writeTestFile(t, dir, "notes.txt", "first\nsecond\nthird\n")
+	repoID := addTestRepo(t, gm, dir)
+
+	blame, err := gm.GetBlame(repoID, "notes.txt", "")
+	if err != nil {
+		t.Fatalf("GetBlame: %v", err)
+	}
+	if len(blame.Lines) != 3 {
+		t.Fatalf("blame has %d lines, want 3: %+v", len(blame.Lines), blame.Lines)
+	}
+
+	first, second, third := blame.Lines[0], blame.Lines[1], blame.Lines[2]
+	if len(first.Hash) != hashLength || first.Summary != "Add notes" || first.Author != "Test" || first.Uncommitted {
+		t.Errorf("first line = %+v", first)
+	}
+	if second.Summary != "Add a second line" || second.LineNumber != 2 || second.Content != "second" {
+		t.Errorf("second line = %+v", second)
+	}
+	if !third.Uncommitted || third.Content != "third" {
+		t.Errorf("third line = %+v, want it uncommitted", third)
+	}
+}
+
+func TestGetBlame(t *testing.T) {
+	testBlame(t, "sha1", 40)
+}
+
+func TestGetBlameSHA256(t *testing.T) {
+	testBlame(t, "sha256", 64)
+}
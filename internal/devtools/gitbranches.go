@@ -0,0 +1,327 @@
+package devtools
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitBranch represents a local or remote-tracking branch
+type GitBranch struct {
+	Name         string    `json:"name"`             // e.g. "main" or "origin/main"
+	Remote       string    `json:"remote,omitempty"` // set for remote-tracking branches
+	IsRemote     bool      `json:"isRemote"`
+	IsCurrent    bool      `json:"isCurrent"`
+	Upstream     string    `json:"upstream,omitempty"`
+	UpstreamGone bool      `json:"upstreamGone"`
+	Ahead        int       `json:"ahead"`
+	Behind       int       `json:"behind"`
+	CommitHash   string    `json:"commitHash"`
+	CommitShort  string    `json:"commitShort"`
+	Subject      string    `json:"subject"`
+	Author       string    `json:"author"`
+	Date         time.Time `json:"date"`
+}
+
+// GitCheckoutResult reports the outcome of switching branches
+type GitCheckoutResult struct {
+	Branch       string `json:"branch"`
+	Stashed      bool   `json:"stashed"`            // local changes were stashed before switching
+	Upstream     string `json:"upstream,omitempty"` // upstream of the branch switched to
+	TracksRemote bool   `json:"tracksRemote"`       // for a remote branch, whether the local branch tracks it
+}
+
+// Dirty tree policies for CheckoutBranch
+const (
+	DirtyRefuse = "refuse"
+	DirtyStash  = "stash"
+)
+
+// gitBranchFormat prints the fields of a GitBranch for git for-each-ref
+const gitBranchFormat = "--format=%(refname)%1f%(refname:short)%1f%(HEAD)%1f%(upstream:short)%1f" +
+	"%(upstream:track,nobracket)%1f%(objectname)%1f%(objectname:short)%1f%(subject)%1f%(authorname)%1f" +
+	"%(authordate:iso-strict)%1e"
+
+// ListBranches returns the local and remote-tracking branches of a repository
+func (gm *GitRepoManager) ListBranches(repoID string) ([]GitBranch, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := runGit(path, "for-each-ref", gitBranchFormat, "refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, err
+	}
+
+	branches := []GitBranch{}
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.Split(strings.TrimPrefix(record, "\n"), "\x1f")
+		if len(fields) < 10 {
+			continue
+		}
+
+		// Skip symbolic refs such as origin/HEAD
+		refName := fields[0]
+		if strings.HasPrefix(refName, "refs/remotes/") && strings.HasSuffix(refName, "/HEAD") {
+			continue
+		}
+
+		branch := GitBranch{
+			Name:        fields[1],
+			IsCurrent:   fields[2] == "*",
+			Upstream:    fields[3],
+			CommitHash:  fields[5],
+			CommitShort: fields[6],
+			Subject:     fields[7],
+			Author:      fields[8],
+		}
+		if strings.HasPrefix(refName, "refs/remotes/") {
+			branch.IsRemote = true
+			branch.Remote = strings.SplitN(strings.TrimPrefix(refName, "refs/remotes/"), "/", 2)[0]
+		}
+		branch.Ahead, branch.Behind, branch.UpstreamGone = parseTrack(fields[4])
+		if date, err := time.Parse(time.RFC3339, fields[9]); err == nil {
+			branch.Date = date
+		}
+		branches = append(branches, branch)
+	}
+	return branches, nil
+}
+
+// parseTrack parses %(upstream:track,nobracket), e.g. "ahead 1, behind 2" or "gone"
+func parseTrack(track string) (ahead, behind int, gone bool) {
+	if track == "gone" {
+		return 0, 0, true
+	}
+	for _, part := range strings.Split(track, ", ") {
+		fields := strings.Fields(part)
+		if len(fields) != 2 {
+			continue
+		}
+		count, _ := strconv.Atoi(fields[1])
+		switch fields[0] {
+		case "ahead":
+			ahead = count
+		case "behind":
+			behind = count
+		}
+	}
+	return ahead, behind, false
+}
+
+// CreateBranch creates a branch from a ref (HEAD if empty) and optionally switches to it
+func (gm *GitRepoManager) CreateBranch(repoID, name, startPoint string, checkout bool) (GitBranch, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return GitBranch{}, err
+	}
+
+	if err := checkBranchName(path, name); err != nil {
+		return GitBranch{}, err
+	}
+	if startPoint == "" {
+		startPoint = "HEAD"
+	}
+	if strings.HasPrefix(startPoint, "-") {
+		return GitBranch{}, &GitError{Op: "branch", Code: GitErrInvalidRef, Message: fmt.Sprintf("invalid start point: %s", startPoint)}
+	}
+	if _, err := runGit(path, "rev-parse", "--verify", "--quiet", "refs/heads/"+name); err == nil {
+		return GitBranch{}, &GitError{Op: "branch", Code: GitErrExists, Message: fmt.Sprintf("branch %s already exists", name)}
+	}
+
+	if checkout {
+		if _, err := runGit(path, "switch", "-c", name, startPoint); err != nil {
+			return GitBranch{}, err
+		}
+		gm.RefreshRepo(repoID)
+	} else if _, err := runGit(path, "branch", name, startPoint); err != nil {
+		return GitBranch{}, err
+	}
+
+	return gm.findBranch(repoID, name, false)
+}
+
+// CheckoutBranch switches to a branch. Remote branches get a local tracking
+// branch, or switch to the local branch of the same name when it already
+// exists. When the working tree has uncommitted changes the switch is refused,
+// or with DirtyStash the changes are stashed first.
+func (gm *GitRepoManager) CheckoutBranch(repoID, name, dirtyPolicy string) (GitCheckoutResult, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return GitCheckoutResult{}, err
+	}
+
+	if name == "" || strings.HasPrefix(name, "-") {
+		return GitCheckoutResult{}, &GitError{Op: "switch", Code: GitErrInvalidRef, Message: fmt.Sprintf("invalid branch name: %s", name)}
+	}
+
+	result := GitCheckoutResult{Branch: name}
+	if err := checkCleanTree(path, "switch"); err != nil {
+		if dirtyPolicy != DirtyStash {
+			return GitCheckoutResult{}, err
+		}
+		var gitErr *GitError
+		if !errors.As(err, &gitErr) || gitErr.Code != GitErrDirty {
+			return GitCheckoutResult{}, err
+		}
+		message := fmt.Sprintf("DevEx: auto-stash before switching to %s", name)
+		if _, err := runGit(path, "stash", "push", "-m", message); err != nil {
+			return GitCheckoutResult{}, err
+		}
+		result.Stashed = true
+	}
+
+	// Remote branches are checked out as a local branch tracking them
+	args := []string{"switch", name}
+	if branches, err := gm.ListBranches(repoID); err == nil {
+		locals := make(map[string]GitBranch)
+		for _, branch := range branches {
+			if !branch.IsRemote {
+				locals[branch.Name] = branch
+			}
+		}
+		_, isLocal := locals[name]
+		result.Upstream = locals[name].Upstream
+
+		for _, branch := range branches {
+			if !isLocal && branch.IsRemote && branch.Name == name {
+				local := strings.TrimPrefix(name, branch.Remote+"/")
+				result.Branch = local
+				if existing, exists := locals[local]; exists {
+					args = []string{"switch", local}
+					result.Upstream = existing.Upstream
+					result.TracksRemote = existing.Upstream == name
+				} else {
+					args = []string{"switch", "-c", local, "--track", name}
+					result.Upstream = name
+					result.TracksRemote = true
+				}
+				break
+			}
+		}
+	}
+
+	if _, err := runGit(path, args...); err != nil {
+		// Put the stashed changes back so nothing seems lost
+		if result.Stashed {
+			runGit(path, "stash", "pop")
+		}
+		return GitCheckoutResult{}, err
+	}
+
+	gm.RefreshRepo(repoID)
+	return result, nil
+}
+
+// DeleteBranch deletes a local branch. Unless force is set, the branch must be
+// merged into its upstream, or into HEAD when it has none.
+func (gm *GitRepoManager) DeleteBranch(repoID, name string, force bool) error {
+	branch, err := gm.findBranch(repoID, name, false)
+	if err != nil {
+		return err
+	}
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return err
+	}
+
+	if branch.IsCurrent {
+		return &GitError{Op: "branch", Code: GitErrCurrentBranch, Message: fmt.Sprintf("branch %s is checked out", name)}
+	}
+
+	if !force {
+		target := "HEAD"
+		if branch.Upstream != "" && !branch.UpstreamGone {
+			target = branch.Upstream
+		}
+		merged, err := isAncestor(path, "refs/heads/"+name, target)
+		if err != nil {
+			return err
+		}
+		if !merged {
+			return &GitError{Op: "branch", Code: GitErrNotMerged, Message: fmt.Sprintf("branch %s is not fully merged into %s", name, target)}
+		}
+	}
+
+	_, err = runGit(path, "branch", "-D", name)
+	return err
+}
+
+// SetUpstream sets the upstream of a local branch, or unsets it when upstream is empty
+func (gm *GitRepoManager) SetUpstream(repoID, name, upstream string) (GitBranch, error) {
+	if _, err := gm.findBranch(repoID, name, false); err != nil {
+		return GitBranch{}, err
+	}
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return GitBranch{}, err
+	}
+
+	if upstream == "" {
+		_, err = runGit(path, "branch", "--unset-upstream", name)
+	} else if strings.HasPrefix(upstream, "-") {
+		return GitBranch{}, &GitError{Op: "branch", Code: GitErrInvalidRef, Message: fmt.Sprintf("invalid upstream: %s", upstream)}
+	} else {
+		_, err = runGit(path, "branch", "--set-upstream-to="+upstream, name)
+	}
+	if err != nil {
+		return GitBranch{}, err
+	}
+
+	return gm.findBranch(repoID, name, false)
+}
+
+// findBranch returns a branch by name
+func (gm *GitRepoManager) findBranch(repoID, name string, remote bool) (GitBranch, error) {
+	branches, err := gm.ListBranches(repoID)
+	if err != nil {
+		return GitBranch{}, err
+	}
+	for _, branch := range branches {
+		if branch.Name == name && branch.IsRemote == remote {
+			return branch, nil
+		}
+	}
+	return GitBranch{}, &GitError{Op: "branch", Code: GitErrNotFound, Message: fmt.Sprintf("branch %s not found", name)}
+}
+
+// checkBranchName validates a new branch name with git check-ref-format
+func checkBranchName(path, name string) error {
+	if name == "" || strings.HasPrefix(name, "-") {
+		return &GitError{Op: "branch", Code: GitErrInvalidName, Message: fmt.Sprintf("invalid branch name: %s", name)}
+	}
+	if _, err := runGit(path, "check-ref-format", "--branch", name); err != nil {
+		return &GitError{Op: "branch", Code: GitErrInvalidName, Message: fmt.Sprintf("invalid branch name: %s", name)}
+	}
+	return nil
+}
+
+// checkCleanTree returns a GitErrDirty error when tracked files have uncommitted changes
+func checkCleanTree(path, op string) error {
+	output, err := runGit(path, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(output) != "" {
+		return &GitError{Op: op, Code: GitErrDirty, Message: "the working tree has uncommitted changes"}
+	}
+	return nil
+}
+
+// isAncestor reports whether commit a is an ancestor of (or equal to) commit b
+func isAncestor(path, a, b string) (bool, error) {
+	err := exec.Command("git", "-C", path, "merge-base", "--is-ancestor", a, b).Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return false, nil
+	default:
+		return false, &GitError{Op: "merge-base", Code: GitErrCommand, Message: err.Error()}
+	}
+}
@@ -0,0 +1,380 @@
+package devtools
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestRepoManager returns a GitRepoManager backed by an in-memory database
+// and runs git with a fixed identity and no user or system configuration
+func newTestRepoManager(t *testing.T) *GitRepoManager {
+	t.Helper()
+
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// Every connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	gm := &GitRepoManager{repos: make(map[string]*GitRepoInfo), db: db}
+	gm.initDB()
+	return gm
+}
+
+// addTestRepo registers the repository at path and returns its ID
+func addTestRepo(t *testing.T, gm *GitRepoManager, path string) string {
+	t.Helper()
+
+	repo, err := gm.AddRepo(GitRepoInfo{Name: filepath.Base(path), Path: path})
+	if err != nil {
+		t.Fatalf("AddRepo: %v", err)
+	}
+	return repo.ID
+}
+
+// gitTest runs git in dir and returns its trimmed output
+func gitTest(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// writeTestFile writes a file in a repository, creating its directory
+func writeTestFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+// commitTestFile writes a file and commits it
+func commitTestFile(t *testing.T, dir, name, content, message string) {
+	t.Helper()
+
+	writeTestFile(t, dir, name, content)
+	gitTest(t, dir, "add", "--", name)
+	gitTest(t, dir, "commit", "-q", "-m", message)
+}
+
+// initTestRepo creates a repository with one commit on main
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("create repository directory: %v", err)
+	}
+	gitTest(t, dir, "init", "-q", "-b", "main")
+	commitTestFile(t, dir, "README.md", "hello\n", "Initial commit")
+	return dir
+}
+
+// cloneTestRepo creates a bare repository with one commit on main and returns
+// it together with a clone of it
+func cloneTestRepo(t *testing.T) (remote, clone string) {
+	t.Helper()
+
+	seed := initTestRepo(t)
+	remote = filepath.Join(t.TempDir(), "remote.git")
+	gitTest(t, seed, "clone", "-q", "--bare", seed, remote)
+
+	clone = filepath.Join(t.TempDir(), "clone")
+	gitTest(t, seed, "clone", "-q", remote, clone)
+	return remote, clone
+}
+
+// wantGitError fails the test unless err is a GitError with code
+func wantGitError(t *testing.T, err error, code string) {
+	t.Helper()
+
+	var gitErr *GitError
+	if !errors.As(err, &gitErr) || gitErr.Code != code {
+		t.Fatalf("error = %v, want a GitError with code %s", err, code)
+	}
+}
+
+// currentBranch returns the branch checked out in dir
+func currentBranch(t *testing.T, dir string) string {
+	t.Helper()
+	return gitTest(t, dir, "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+func TestListBranches(t *testing.T) {
+	gm := newTestRepoManager(t)
+	_, clone := cloneTestRepo(t)
+	gitTest(t, clone, "switch", "-q", "-c", "feature")
+	commitTestFile(t, clone, "feature.txt", "feature\n", "Add feature")
+	gitTest(t, clone, "switch", "-q", "main")
+	commitTestFile(t, clone, "main.txt", "main\n", "Work on main")
+	repoID := addTestRepo(t, gm, clone)
+
+	branches, err := gm.ListBranches(repoID)
+	if err != nil {
+		t.Fatalf("ListBranches: %v", err)
+	}
+
+	byName := make(map[string]GitBranch)
+	for _, branch := range branches {
+		byName[branch.Name] = branch
+	}
+
+	main, ok := byName["main"]
+	if !ok || main.IsRemote || !main.IsCurrent {
+		t.Fatalf("main = %+v, want the current local branch", main)
+	}
+	if main.Upstream != "origin/main" || main.Ahead != 1 || main.Behind != 0 {
+		t.Errorf("main tracks %q ahead %d behind %d, want origin/main ahead 1", main.Upstream, main.Ahead, main.Behind)
+	}
+	if main.Subject != "Work on main" || main.Author != "Test" || len(main.CommitHash) != 40 {
+		t.Errorf("main commit = %q by %q (%s)", main.Subject, main.Author, main.CommitHash)
+	}
+
+	if feature, ok := byName["feature"]; !ok || feature.IsCurrent || feature.Upstream != "" {
+		t.Errorf("feature = %+v, want a local branch without upstream", feature)
+	}
+	if remote, ok := byName["origin/main"]; !ok || !remote.IsRemote || remote.Remote != "origin" {
+		t.Errorf("origin/main = %+v, want a remote-tracking branch of origin", remote)
+	}
+	if _, ok := byName["origin/HEAD"]; ok {
+		t.Errorf("origin/HEAD is listed as a branch")
+	}
+}
+
+func TestCreateBranch(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	repoID := addTestRepo(t, gm, dir)
+
+	branch, err := gm.CreateBranch(repoID, "topic", "", false)
+	if err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	if branch.Name != "topic" || branch.IsCurrent {
+		t.Errorf("branch = %+v, want topic without switching to it", branch)
+	}
+	if current := currentBranch(t, dir); current != "main" {
+		t.Errorf("current branch = %s, want main", current)
+	}
+
+	_, err = gm.CreateBranch(repoID, "topic", "", false)
+	wantGitError(t, err, GitErrExists)
+	_, err = gm.CreateBranch(repoID, "bad..name", "", false)
+	wantGitError(t, err, GitErrInvalidName)
+	_, err = gm.CreateBranch(repoID, "other", "--orphan", false)
+	wantGitError(t, err, GitErrInvalidRef)
+
+	if _, err := gm.CreateBranch(repoID, "work", "topic", true); err != nil {
+		t.Fatalf("CreateBranch with checkout: %v", err)
+	}
+	if current := currentBranch(t, dir); current != "work" {
+		t.Errorf("current branch = %s, want work", current)
+	}
+	if branch := gm.repos[repoID].Branch; branch != "work" {
+		t.Errorf("repository branch = %s, want it refreshed to work", branch)
+	}
+}
+
+func TestCheckoutBranchRefusesDirtyTree(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	gitTest(t, dir, "branch", "topic")
+	writeTestFile(t, dir, "README.md", "changed\n")
+	repoID := addTestRepo(t, gm, dir)
+
+	_, err := gm.CheckoutBranch(repoID, "topic", DirtyRefuse)
+	wantGitError(t, err, GitErrDirty)
+	if current := currentBranch(t, dir); current != "main" {
+		t.Errorf("current branch = %s, want main", current)
+	}
+
+	// Untracked files don't block a switch
+	gitTest(t, dir, "checkout", "--", "README.md")
+	writeTestFile(t, dir, "notes.txt", "untracked\n")
+	if _, err := gm.CheckoutBranch(repoID, "topic", DirtyRefuse); err != nil {
+		t.Fatalf("CheckoutBranch with untracked files: %v", err)
+	}
+}
+
+func TestCheckoutBranchStashesDirtyTree(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	gitTest(t, dir, "branch", "topic")
+	writeTestFile(t, dir, "README.md", "changed\n")
+	repoID := addTestRepo(t, gm, dir)
+
+	result, err := gm.CheckoutBranch(repoID, "topic", DirtyStash)
+	if err != nil {
+		t.Fatalf("CheckoutBranch: %v", err)
+	}
+	if !result.Stashed || result.Branch != "topic" {
+		t.Errorf("result = %+v, want topic with the changes stashed", result)
+	}
+	if current := currentBranch(t, dir); current != "topic" {
+		t.Errorf("current branch = %s, want topic", current)
+	}
+	if stashes := gitTest(t, dir, "stash", "list"); !strings.Contains(stashes, "auto-stash before switching to topic") {
+		t.Errorf("stash list = %q, want the auto-stash", stashes)
+	}
+	if status := gitTest(t, dir, "status", "--porcelain"); status != "" {
+		t.Errorf("status = %q, want a clean tree", status)
+	}
+}
+
+func TestCheckoutBranchRestoresStashOnFailure(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	writeTestFile(t, dir, "README.md", "changed\n")
+	repoID := addTestRepo(t, gm, dir)
+
+	if _, err := gm.CheckoutBranch(repoID, "missing", DirtyStash); err == nil {
+		t.Fatalf("CheckoutBranch to a missing branch succeeded")
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "README.md")); string(content) != "changed\n" {
+		t.Errorf("README.md = %q, want the local change restored", content)
+	}
+	if stashes := gitTest(t, dir, "stash", "list"); stashes != "" {
+		t.Errorf("stash list = %q, want it empty", stashes)
+	}
+}
+
+func TestCheckoutRemoteBranch(t *testing.T) {
+	gm := newTestRepoManager(t)
+	remote, clone := cloneTestRepo(t)
+
+	// Publish a branch from a second clone
+	other := filepath.Join(t.TempDir(), "other")
+	gitTest(t, clone, "clone", "-q", remote, other)
+	gitTest(t, other, "switch", "-q", "-c", "feature")
+	commitTestFile(t, other, "feature.txt", "feature\n", "Add feature")
+	gitTest(t, other, "push", "-q", "origin", "feature")
+	gitTest(t, clone, "fetch", "-q")
+	repoID := addTestRepo(t, gm, clone)
+
+	result, err := gm.CheckoutBranch(repoID, "origin/feature", DirtyRefuse)
+	if err != nil {
+		t.Fatalf("CheckoutBranch: %v", err)
+	}
+	if result.Branch != "feature" || !result.TracksRemote || result.Upstream != "origin/feature" {
+		t.Errorf("result = %+v, want a local feature branch tracking origin/feature", result)
+	}
+	if upstream := gitTest(t, clone, "rev-parse", "--abbrev-ref", "feature@{upstream}"); upstream != "origin/feature" {
+		t.Errorf("feature tracks %s, want origin/feature", upstream)
+	}
+
+	// With the local branch in place, checking out the remote switches to it
+	gitTest(t, clone, "switch", "-q", "main")
+	result, err = gm.CheckoutBranch(repoID, "origin/feature", DirtyRefuse)
+	if err != nil {
+		t.Fatalf("CheckoutBranch with an existing local branch: %v", err)
+	}
+	if result.Branch != "feature" || !result.TracksRemote {
+		t.Errorf("result = %+v, want the existing feature branch", result)
+	}
+	if current := currentBranch(t, clone); current != "feature" {
+		t.Errorf("current branch = %s, want feature", current)
+	}
+
+	// A local branch of the same name that tracks something else is reported
+	gitTest(t, clone, "switch", "-q", "main")
+	gitTest(t, clone, "branch", "--unset-upstream", "feature")
+	result, err = gm.CheckoutBranch(repoID, "origin/feature", DirtyRefuse)
+	if err != nil {
+		t.Fatalf("CheckoutBranch with an untracked local branch: %v", err)
+	}
+	if result.Branch != "feature" || result.TracksRemote || result.Upstream != "" {
+		t.Errorf("result = %+v, want feature reported as not tracking origin/feature", result)
+	}
+}
+
+func TestDeleteBranch(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	gitTest(t, dir, "branch", "merged")
+	gitTest(t, dir, "switch", "-q", "-c", "unmerged")
+	commitTestFile(t, dir, "work.txt", "work\n", "Unmerged work")
+	gitTest(t, dir, "switch", "-q", "main")
+	repoID := addTestRepo(t, gm, dir)
+
+	wantGitError(t, gm.DeleteBranch(repoID, "main", false), GitErrCurrentBranch)
+	wantGitError(t, gm.DeleteBranch(repoID, "missing", false), GitErrNotFound)
+	wantGitError(t, gm.DeleteBranch(repoID, "unmerged", false), GitErrNotMerged)
+
+	if err := gm.DeleteBranch(repoID, "merged", false); err != nil {
+		t.Fatalf("DeleteBranch merged: %v", err)
+	}
+	if err := gm.DeleteBranch(repoID, "unmerged", true); err != nil {
+		t.Fatalf("DeleteBranch unmerged with force: %v", err)
+	}
+	if branches := gitTest(t, dir, "branch", "--format=%(refname:short)"); branches != "main" {
+		t.Errorf("branches = %q, want only main", branches)
+	}
+}
+
+func TestDeleteBranchChecksUpstream(t *testing.T) {
+	gm := newTestRepoManager(t)
+	_, clone := cloneTestRepo(t)
+
+	// Pushed work is merged into the upstream even though HEAD doesn't have it
+	gitTest(t, clone, "switch", "-q", "-c", "pushed")
+	commitTestFile(t, clone, "pushed.txt", "pushed\n", "Pushed work")
+	gitTest(t, clone, "push", "-q", "-u", "origin", "pushed")
+	gitTest(t, clone, "switch", "-q", "main")
+	repoID := addTestRepo(t, gm, clone)
+
+	if err := gm.DeleteBranch(repoID, "pushed", false); err != nil {
+		t.Fatalf("DeleteBranch of a pushed branch: %v", err)
+	}
+}
+
+func TestSetUpstream(t *testing.T) {
+	gm := newTestRepoManager(t)
+	_, clone := cloneTestRepo(t)
+	gitTest(t, clone, "branch", "--no-track", "topic", "origin/main")
+	repoID := addTestRepo(t, gm, clone)
+
+	branch, err := gm.SetUpstream(repoID, "topic", "origin/main")
+	if err != nil {
+		t.Fatalf("SetUpstream: %v", err)
+	}
+	if branch.Upstream != "origin/main" {
+		t.Errorf("upstream = %q, want origin/main", branch.Upstream)
+	}
+
+	branch, err = gm.SetUpstream(repoID, "topic", "")
+	if err != nil {
+		t.Fatalf("SetUpstream unset: %v", err)
+	}
+	if branch.Upstream != "" {
+		t.Errorf("upstream = %q, want none", branch.Upstream)
+	}
+
+	_, err = gm.SetUpstream(repoID, "topic", "--delete")
+	wantGitError(t, err, GitErrInvalidRef)
+	_, err = gm.SetUpstream(repoID, "missing", "origin/main")
+	wantGitError(t, err, GitErrNotFound)
+}
@@ -0,0 +1,232 @@
+package devtools
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// maxCountedFileSize caps how much of an untracked file is read to count its lines
+const maxCountedFileSize = 1 << 20
+
+// GitFileChange is a changed file in the working tree or index. IndexStatus and
+// WorktreeStatus use git's status letters, with "." meaning unchanged and "?"
+// for untracked files.
+type GitFileChange struct {
+	Path           string `json:"path"`
+	OrigPath       string `json:"origPath,omitempty"` // set for renames and copies
+	IndexStatus    string `json:"indexStatus"`
+	WorktreeStatus string `json:"worktreeStatus"`
+	Conflicted     bool   `json:"conflicted"`
+	Untracked      bool   `json:"untracked"`
+	Binary         bool   `json:"binary"`
+	Additions      int    `json:"additions"`
+	Deletions      int    `json:"deletions"`
+}
+
+// GitDiffLine is a line of a diff hunk. Type is "context", "add", "delete" or
+// "meta" (such as "\ No newline at end of file"); line numbers are 0 when the
+// line doesn't exist on that side.
+type GitDiffLine struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
+	OldLine int    `json:"oldLine"`
+	NewLine int    `json:"newLine"`
+}
+
+// GitDiffHunk is a hunk of a unified diff
+type GitDiffHunk struct {
+	Header   string        `json:"header"`
+	OldStart int           `json:"oldStart"`
+	OldLines int           `json:"oldLines"`
+	NewStart int           `json:"newStart"`
+	NewLines int           `json:"newLines"`
+	Lines    []GitDiffLine `json:"lines"`
+}
+
+// GitFileDiff is the parsed diff of a single file
+type GitFileDiff struct {
+	Path      string        `json:"path"`
+	OldPath   string        `json:"oldPath,omitempty"`
+	Staged    bool          `json:"staged"`
+	Binary    bool          `json:"binary"`
+	Hunks     []GitDiffHunk `json:"hunks"`
+	Additions int           `json:"additions"`
+	Deletions int           `json:"deletions"`
+}
+
+// GetFileDiff returns the diff of a file, either of its staged changes (index
+// against HEAD) or its unstaged changes (working tree against the index).
+// Untracked files are diffed against an empty file.
+func (gm *GitRepoManager) GetFileDiff(repoID, file string, staged bool) (GitFileDiff, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return GitFileDiff{}, err
+	}
+
+	args := []string{"diff", "--no-color", "--no-ext-diff", "-M"}
+	pathspec := []string{"--", file}
+	if staged {
+		args = append(args, "--cached")
+
+		// Include the original path of a staged rename so it isn't shown as an added file
+		if status, err := readStatus(path); err == nil {
+			for _, change := range status.Files {
+				if change.Path == file && change.OrigPath != "" {
+					pathspec = append(pathspec, change.OrigPath)
+				}
+			}
+		}
+	}
+	output, err := runGit(path, append(args, pathspec...)...)
+	if err != nil {
+		return GitFileDiff{}, err
+	}
+
+	// Untracked files have no diff against the index
+	if output == "" && !staged {
+		untracked, err := runGit(path, "ls-files", "--others", "--exclude-standard", "--", file)
+		if err != nil {
+			return GitFileDiff{}, err
+		}
+		if strings.TrimSpace(untracked) != "" {
+			if output, err = diffNoIndex(path, os.DevNull, file); err != nil {
+				return GitFileDiff{}, err
+			}
+		}
+	}
+
+	diff := parseUnifiedDiff(output)
+	diff.Path = file
+	diff.Staged = staged
+	return diff, nil
+}
+
+// diffNoIndex diffs two files outside the index. git diff --no-index exits
+// with 1 when the files differ, which isn't an error here.
+func diffNoIndex(path, oldFile, newFile string) (string, error) {
+	cmd := exec.Command("git", "-C", path, "diff", "--no-color", "--no-ext-diff", "--no-index", "--", oldFile, newFile)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return "", &GitError{Op: "diff", Code: GitErrCommand, Message: strings.TrimSpace(stderr.String())}
+	}
+	return string(output), nil
+}
+
+// parseUnifiedDiff parses git diff output for a single file
+func parseUnifiedDiff(output string) GitFileDiff {
+	diff := GitFileDiff{Hunks: []GitDiffHunk{}}
+	var hunk *GitDiffHunk
+	oldLine, newLine := 0, 0
+
+	lines := strings.Split(output, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for _, line := range lines {
+		// Headers before the first hunk
+		if hunk == nil && !strings.HasPrefix(line, "@@") {
+			switch {
+			case strings.HasPrefix(line, "Binary files "), strings.HasPrefix(line, "GIT binary patch"):
+				diff.Binary = true
+			case strings.HasPrefix(line, "rename from "):
+				diff.OldPath = strings.TrimPrefix(line, "rename from ")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "@@") {
+			diff.Hunks = append(diff.Hunks, parseHunkHeader(line))
+			hunk = &diff.Hunks[len(diff.Hunks)-1]
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			continue
+		}
+
+		// A new file header means the output covers more than one file
+		if strings.HasPrefix(line, "diff --git ") {
+			break
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+"):
+			hunk.Lines = append(hunk.Lines, GitDiffLine{Type: "add", Content: line[1:], NewLine: newLine})
+			newLine++
+			diff.Additions++
+		case strings.HasPrefix(line, "-"):
+			hunk.Lines = append(hunk.Lines, GitDiffLine{Type: "delete", Content: line[1:], OldLine: oldLine})
+			oldLine++
+			diff.Deletions++
+		case strings.HasPrefix(line, "\\"):
+			hunk.Lines = append(hunk.Lines, GitDiffLine{Type: "meta", Content: line})
+		default:
+			content := strings.TrimPrefix(line, " ")
+			hunk.Lines = append(hunk.Lines, GitDiffLine{Type: "context", Content: content, OldLine: oldLine, NewLine: newLine})
+			oldLine++
+			newLine++
+		}
+	}
+	return diff
+}
+
+// parseHunkHeader parses a header like "@@ -1,4 +1,5 @@ func main() {"
+func parseHunkHeader(header string) GitDiffHunk {
+	hunk := GitDiffHunk{Header: header, Lines: []GitDiffLine{}}
+
+	fields := strings.Fields(header)
+	if len(fields) < 3 {
+		return hunk
+	}
+	hunk.OldStart, hunk.OldLines = parseHunkRange(strings.TrimPrefix(fields[1], "-"))
+	hunk.NewStart, hunk.NewLines = parseHunkRange(strings.TrimPrefix(fields[2], "+"))
+	return hunk
+}
+
+// parseHunkRange parses "start,count" where the count defaults to 1
+func parseHunkRange(value string) (int, int) {
+	start, count, found := strings.Cut(value, ",")
+	startLine, _ := strconv.Atoi(start)
+	if !found {
+		return startLine, 1
+	}
+	lineCount, _ := strconv.Atoi(count)
+	return startLine, lineCount
+}
+
+// countFileLines counts the lines of a text file and reports whether it is
+// binary. Only the first maxCountedFileSize bytes are read.
+func countFileLines(path string) (int, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxCountedFileSize))
+	if err != nil || len(data) == 0 {
+		return 0, false
+	}
+
+	// Git treats files with a NUL byte near the start as binary
+	sniff := data
+	if len(sniff) > 8000 {
+		sniff = sniff[:8000]
+	}
+	if bytes.IndexByte(sniff, 0) >= 0 {
+		return 0, true
+	}
+
+	lines := bytes.Count(data, []byte("\n"))
+	if data[len(data)-1] != '\n' {
+		lines++
+	}
+	return lines, false
+}
@@ -0,0 +1,51 @@
+package devtools
+
+import "testing"
+
+func TestGetFileDiff(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	commitTestFile(t, dir, "app.txt", "one\ntwo\n", "Add app")
+	writeTestFile(t, dir, "app.txt", "one\n2\n")
+	writeTestFile(t, dir, "new.txt", "new\n")
+	repoID := addTestRepo(t, gm, dir)
+
+	diff, err := gm.GetFileDiff(repoID, "app.txt", false)
+	if err != nil {
+		t.Fatalf("GetFileDiff: %v", err)
+	}
+	if len(diff.Hunks) != 1 || diff.Path != "app.txt" || diff.Staged {
+		t.Errorf("diff = %+v, want one unstaged hunk", diff)
+	}
+
+	// Untracked files are diffed against an empty file
+	diff, err = gm.GetFileDiff(repoID, "new.txt", false)
+	if err != nil {
+		t.Fatalf("GetFileDiff of an untracked file: %v", err)
+	}
+	if len(diff.Hunks) != 1 {
+		t.Errorf("untracked diff = %+v, want one hunk", diff)
+	}
+
+	gitTest(t, dir, "add", "app.txt")
+	if diff, err = gm.GetFileDiff(repoID, "app.txt", true); err != nil || len(diff.Hunks) != 1 || !diff.Staged {
+		t.Errorf("staged diff = %+v, %v, want one staged hunk", diff, err)
+	}
+}
+
+func TestGetFileDiffTakesPathsLiterally(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	commitTestFile(t, dir, "star*.txt", "unchanged\n", "Add a file with a glob in its name")
+	commitTestFile(t, dir, "starX.txt", "before\n", "Add a file the glob matches")
+	writeTestFile(t, dir, "starX.txt", "after\n")
+	repoID := addTestRepo(t, gm, dir)
+
+	diff, err := gm.GetFileDiff(repoID, "star*.txt", false)
+	if err != nil {
+		t.Fatalf("GetFileDiff: %v", err)
+	}
+	if len(diff.Hunks) != 0 {
+		t.Errorf("diff of the unchanged star*.txt = %+v, want no hunks", diff.Hunks)
+	}
+}
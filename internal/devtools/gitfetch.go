@@ -0,0 +1,405 @@
+package devtools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitBehindEvent is the Wails event emitted when a fetch leaves a tracked branch behind its upstream
+const GitBehindEvent = "gitrepo:behind"
+
+// fetchTimeout bounds a single git fetch so an unreachable remote can't stall the scheduler
+const fetchTimeout = 2 * time.Minute
+
+// fetchSettingsKey is the git_settings key the scheduler's settings are stored under
+const fetchSettingsKey = "auto_fetch"
+
+// GitFetchConfig configures the background fetch scheduler
+type GitFetchConfig struct {
+	IntervalSeconds int `json:"intervalSeconds"`
+	Concurrency     int `json:"concurrency"` // repositories fetched at the same time
+}
+
+// GitFetchStatus reports the state of the background fetch scheduler
+type GitFetchStatus struct {
+	Running   bool           `json:"running"`
+	Config    GitFetchConfig `json:"config"`
+	LastRun   time.Time      `json:"lastRun"`
+	NextRun   time.Time      `json:"nextRun"`
+	LastError string         `json:"lastError,omitempty"`
+}
+
+// GitRemoteChange describes commits that arrived on the upstream of a local branch
+type GitRemoteChange struct {
+	RepoID     string      `json:"repoId"`
+	RepoName   string      `json:"repoName"`
+	Branch     string      `json:"branch"`
+	Upstream   string      `json:"upstream"`
+	Behind     int         `json:"behind"`
+	NewCommits []GitCommit `json:"newCommits"`
+}
+
+// DefaultGitFetchConfig is used for fields of GitFetchConfig that are not set
+var DefaultGitFetchConfig = GitFetchConfig{IntervalSeconds: 300, Concurrency: 4}
+
+// gitFetchSettings is the stored state of the fetch scheduler, restored on startup
+type gitFetchSettings struct {
+	Enabled bool           `json:"enabled"`
+	Config  GitFetchConfig `json:"config"`
+}
+
+// gitFetcher is the state of the background fetch scheduler
+type gitFetcher struct {
+	config   GitFetchConfig
+	cancel   context.CancelFunc
+	done     chan struct{}
+	status   GitFetchStatus
+	inFlight map[string]bool // repositories being fetched
+}
+
+// trackedBranch is a local branch with an upstream
+type trackedBranch struct {
+	name     string
+	upstream string // full ref, e.g. refs/remotes/origin/main
+	short    string // e.g. origin/main
+}
+
+// SetEventEmitter sets the function used to push remote changes to the frontend
+func (gm *GitRepoManager) SetEventEmitter(emit EventEmitter) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+	gm.emit = emit
+}
+
+// SetAutoFetch enables or disables background fetching for a repository
+func (gm *GitRepoManager) SetAutoFetch(repoID string, enabled bool) (GitRepoInfo, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	repo, exists := gm.repos[repoID]
+	if !exists {
+		return GitRepoInfo{}, fmt.Errorf("repository with ID %s not found", repoID)
+	}
+	repo.FetchDisabled = !enabled
+	if err := gm.saveRepoToDB(repo); err != nil {
+		return GitRepoInfo{}, err
+	}
+	return *repo, nil
+}
+
+// StartAutoFetch starts fetching all repositories in the background, replacing
+// a running scheduler
+func (gm *GitRepoManager) StartAutoFetch(config GitFetchConfig) GitFetchStatus {
+	gm.StopAutoFetch()
+
+	if config.IntervalSeconds <= 0 {
+		config.IntervalSeconds = DefaultGitFetchConfig.IntervalSeconds
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = DefaultGitFetchConfig.Concurrency
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	interval := time.Duration(config.IntervalSeconds) * time.Second
+
+	gm.mutex.Lock()
+	if err := gm.saveSetting(fetchSettingsKey, gitFetchSettings{Enabled: true, Config: config}); err != nil {
+		fmt.Printf("Error saving auto-fetch settings: %v\n", err)
+	}
+	fetcher := &gitFetcher{
+		config:   config,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		inFlight: make(map[string]bool),
+		status:   GitFetchStatus{Running: true, Config: config, NextRun: time.Now()},
+	}
+	gm.fetcher = fetcher
+	status := fetcher.status
+	gm.mutex.Unlock()
+
+	run := func() {
+		gm.fetchAll(ctx, fetcher)
+
+		gm.mutex.Lock()
+		fetcher.status.LastRun = time.Now()
+		fetcher.status.NextRun = time.Now().Add(interval)
+		gm.mutex.Unlock()
+	}
+
+	go func() {
+		defer close(fetcher.done)
+
+		// Fetch right away rather than a full interval after starting
+		run()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+
+	return status
+}
+
+// ResumeAutoFetch starts the background fetch scheduler with the settings it
+// last ran with, unless it was disabled. It runs with the default settings
+// when none are stored.
+func (gm *GitRepoManager) ResumeAutoFetch() GitFetchStatus {
+	settings := gitFetchSettings{Enabled: true, Config: DefaultGitFetchConfig}
+	gm.mutex.Lock()
+	gm.loadSetting(fetchSettingsKey, &settings)
+	gm.mutex.Unlock()
+	if !settings.Enabled {
+		return gm.GetAutoFetchStatus()
+	}
+	return gm.StartAutoFetch(settings.Config)
+}
+
+// DisableAutoFetch stops the background fetch scheduler and keeps it from
+// starting again with the app
+func (gm *GitRepoManager) DisableAutoFetch() {
+	gm.StopAutoFetch()
+
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+	settings := gitFetchSettings{Config: DefaultGitFetchConfig}
+	gm.loadSetting(fetchSettingsKey, &settings)
+	settings.Enabled = false
+	if err := gm.saveSetting(fetchSettingsKey, settings); err != nil {
+		fmt.Printf("Error saving auto-fetch settings: %v\n", err)
+	}
+}
+
+// StopAutoFetch stops the background fetch scheduler and waits for running fetches to end
+func (gm *GitRepoManager) StopAutoFetch() {
+	gm.mutex.Lock()
+	fetcher := gm.fetcher
+	gm.fetcher = nil
+	gm.mutex.Unlock()
+
+	if fetcher != nil {
+		fetcher.cancel()
+		<-fetcher.done
+	}
+}
+
+// GetAutoFetchStatus returns the state of the background fetch scheduler
+func (gm *GitRepoManager) GetAutoFetchStatus() GitFetchStatus {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	if gm.fetcher == nil {
+		return GitFetchStatus{Config: DefaultGitFetchConfig}
+	}
+	return gm.fetcher.status
+}
+
+// fetchAll fetches every repository that has auto-fetch enabled, at most
+// config.Concurrency at a time
+func (gm *GitRepoManager) fetchAll(ctx context.Context, fetcher *gitFetcher) {
+	gm.mutex.Lock()
+	repoIDs := make([]string, 0, len(gm.repos))
+	for id, repo := range gm.repos {
+		if !repo.FetchDisabled && !fetcher.inFlight[id] {
+			repoIDs = append(repoIDs, id)
+			fetcher.inFlight[id] = true
+		}
+	}
+	gm.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	var errorMutex sync.Mutex
+	var failures []string
+	slots := make(chan struct{}, fetcher.config.Concurrency)
+
+	for _, id := range repoIDs {
+		wg.Add(1)
+		go func(repoID string) {
+			defer wg.Done()
+			defer func() {
+				gm.mutex.Lock()
+				delete(fetcher.inFlight, repoID)
+				gm.mutex.Unlock()
+			}()
+
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				return
+			}
+
+			if _, err := gm.fetchRepo(ctx, repoID); err != nil && ctx.Err() == nil {
+				errorMutex.Lock()
+				failures = append(failures, fmt.Sprintf("%s: %v", repoID, err))
+				errorMutex.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	gm.mutex.Lock()
+	fetcher.status.LastError = strings.Join(failures, "; ")
+	gm.mutex.Unlock()
+}
+
+// FetchRepo fetches all remotes of a repository and returns the tracked
+// branches that received new upstream commits
+func (gm *GitRepoManager) FetchRepo(repoID string) ([]GitRemoteChange, error) {
+	return gm.fetchRepo(context.Background(), repoID)
+}
+
+// fetchRepo fetches a repository, refreshes it and emits GitBehindEvent for
+// every tracked branch that the fetch left behind its upstream
+func (gm *GitRepoManager) fetchRepo(ctx context.Context, repoID string) ([]GitRemoteChange, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("repository path %s not found", path)
+	}
+
+	// Remember where each upstream pointed before fetching
+	branches, err := trackedBranches(path)
+	if err != nil {
+		return nil, err
+	}
+	before := make(map[string]string, len(branches))
+	for _, branch := range branches {
+		before[branch.upstream] = resolveRef(path, branch.upstream)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	if err := gitFetch(ctx, path); err != nil {
+		return nil, err
+	}
+
+	changes := []GitRemoteChange{}
+	for _, branch := range branches {
+		after := resolveRef(path, branch.upstream)
+		if after == "" || after == before[branch.upstream] {
+			continue
+		}
+
+		// List only the commits this fetch brought in, or everything the
+		// branch lacks when the upstream is new
+		rangeSpec := "refs/heads/" + branch.name + ".." + after
+		if old := before[branch.upstream]; old != "" {
+			rangeSpec = old + ".." + after
+		}
+		output, err := runGit(path, "log", gitCommitFormat, "--end-of-options", rangeSpec, "--")
+		if err != nil {
+			continue
+		}
+		commits := parseCommits(output)
+		if len(commits) == 0 {
+			continue
+		}
+
+		count, err := runGit(path, "rev-list", "--count", "--end-of-options", "refs/heads/"+branch.name+".."+after)
+		if err != nil {
+			continue
+		}
+		behind := 0
+		fmt.Sscanf(strings.TrimSpace(count), "%d", &behind)
+
+		changes = append(changes, GitRemoteChange{
+			RepoID:     repoID,
+			Branch:     branch.name,
+			Upstream:   branch.short,
+			Behind:     behind,
+			NewCommits: commits,
+		})
+	}
+
+	repo, _ := gm.RefreshRepo(repoID)
+
+	gm.mutex.Lock()
+	emit := gm.emit
+	gm.mutex.Unlock()
+	for i := range changes {
+		changes[i].RepoName = repo.Name
+		if emit != nil && changes[i].Behind > 0 {
+			emit(GitBehindEvent, changes[i])
+		}
+	}
+
+	return changes, nil
+}
+
+// trackedBranches returns the local branches of a repository that have an upstream
+func trackedBranches(path string) ([]trackedBranch, error) {
+	output, err := runGit(path, "for-each-ref", "--format=%(refname:short)%1f%(upstream)%1f%(upstream:short)", "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+
+	branches := []trackedBranch{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 3 || fields[1] == "" {
+			continue
+		}
+		branches = append(branches, trackedBranch{name: fields[0], upstream: fields[1], short: fields[2]})
+	}
+	return branches, nil
+}
+
+// resolveRef returns the commit a ref points at, or an empty string if it doesn't exist
+func resolveRef(path, ref string) string {
+	output, err := runGit(path, "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+// gitFetch fetches all remotes without prompting for credentials. Git runs in
+// its own process group so that a timeout also stops ssh and credential helpers.
+func gitFetch(ctx context.Context, path string) error {
+	cmd := exec.CommandContext(ctx, "git", "-C", path, "fetch", "--all", "--prune", "--quiet")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if batchSSHCommand(path) {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	cmd.WaitDelay = commandWaitDelay
+	setProcessGroup(cmd)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if ctx.Err() == context.DeadlineExceeded {
+			message = "timed out"
+		} else if message == "" {
+			message = err.Error()
+		}
+		return &GitError{Op: "fetch", Code: GitErrCommand, Message: message}
+	}
+	return nil
+}
+
+// batchSSHCommand reports whether ssh can be made non-interactive for a
+// repository through GIT_SSH_COMMAND, which is left alone when the user has
+// configured their own ssh command
+func batchSSHCommand(path string) bool {
+	if os.Getenv("GIT_SSH_COMMAND") != "" || os.Getenv("GIT_SSH") != "" {
+		return false
+	}
+	output, _ := runGit(path, "config", "--get", "core.sshCommand")
+	return strings.TrimSpace(output) == ""
+}
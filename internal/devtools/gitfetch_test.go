@@ -0,0 +1,204 @@
+package devtools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordedEvents collects the events emitted by a manager
+type recordedEvents struct {
+	mutex  sync.Mutex
+	events []GitRemoteChange
+}
+
+// emit is an EventEmitter that records GitBehindEvent
+func (r *recordedEvents) emit(eventName string, data ...interface{}) {
+	if eventName != GitBehindEvent || len(data) != 1 {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, data[0].(GitRemoteChange))
+}
+
+// list returns the events recorded so far
+func (r *recordedEvents) list() []GitRemoteChange {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]GitRemoteChange(nil), r.events...)
+}
+
+// pushTestCommits commits files in a second clone of remote and pushes them
+func pushTestCommits(t *testing.T, remote string, subjects ...string) {
+	t.Helper()
+
+	other := filepath.Join(t.TempDir(), "other")
+	gitTest(t, filepath.Dir(other), "clone", "-q", remote, other)
+	for i, subject := range subjects {
+		commitTestFile(t, other, filepath.Join("pushed", string(rune('a'+i))+".txt"), subject+"\n", subject)
+	}
+	gitTest(t, other, "push", "-q", "origin", "main")
+}
+
+func TestFetchRepo(t *testing.T) {
+	gm := newTestRepoManager(t)
+	remote, clone := cloneTestRepo(t)
+	repoID := addTestRepo(t, gm, clone)
+	events := &recordedEvents{}
+	gm.SetEventEmitter(events.emit)
+
+	pushTestCommits(t, remote, "First pushed", "Second pushed")
+
+	changes, err := gm.FetchRepo(repoID)
+	if err != nil {
+		t.Fatalf("FetchRepo: %v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("changes = %+v, want one for main", changes)
+	}
+	change := changes[0]
+	if change.RepoID != repoID || change.RepoName != "clone" || change.Branch != "main" || change.Upstream != "origin/main" || change.Behind != 2 {
+		t.Errorf("change = %+v", change)
+	}
+	if len(change.NewCommits) != 2 || change.NewCommits[0].Subject != "Second pushed" || change.NewCommits[1].Subject != "First pushed" {
+		t.Errorf("new commits = %+v, want the two pushed commits, newest first", change.NewCommits)
+	}
+
+	if got := events.list(); len(got) != 1 || got[0].Behind != 2 {
+		t.Fatalf("events = %+v, want one %s event", got, GitBehindEvent)
+	}
+
+	// Nothing new arrives the second time
+	changes, err = gm.FetchRepo(repoID)
+	if err != nil {
+		t.Fatalf("second FetchRepo: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("second fetch changes = %+v, want none", changes)
+	}
+	if got := events.list(); len(got) != 1 {
+		t.Errorf("events = %+v, want no event for a fetch without new commits", got)
+	}
+
+	gm.mutex.Lock()
+	repo := *gm.repos[repoID]
+	gm.mutex.Unlock()
+	if repo.Behind != 2 {
+		t.Errorf("repository behind = %d, want 2 after the fetch", repo.Behind)
+	}
+}
+
+func TestFetchRepoSkipsBranchesWithoutUpstream(t *testing.T) {
+	gm := newTestRepoManager(t)
+	remote, clone := cloneTestRepo(t)
+	gitTest(t, clone, "switch", "-q", "-c", "local")
+	repoID := addTestRepo(t, gm, clone)
+
+	pushTestCommits(t, remote, "Pushed")
+
+	changes, err := gm.FetchRepo(repoID)
+	if err != nil {
+		t.Fatalf("FetchRepo: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Branch != "main" {
+		t.Errorf("changes = %+v, want main only", changes)
+	}
+}
+
+func TestStartAutoFetchFetchesImmediately(t *testing.T) {
+	gm := newTestRepoManager(t)
+	remote, clone := cloneTestRepo(t)
+	addTestRepo(t, gm, clone)
+	events := &recordedEvents{}
+	gm.SetEventEmitter(events.emit)
+
+	pushTestCommits(t, remote, "Pushed")
+
+	gm.StartAutoFetch(GitFetchConfig{IntervalSeconds: 3600})
+	defer gm.StopAutoFetch()
+
+	deadline := time.Now().Add(30 * time.Second)
+	for gm.GetAutoFetchStatus().LastRun.IsZero() {
+		if time.Now().After(deadline) {
+			t.Fatal("no fetch ran after starting the scheduler")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	status := gm.GetAutoFetchStatus()
+	if status.LastError != "" {
+		t.Errorf("last error = %q", status.LastError)
+	}
+	if !status.NextRun.After(time.Now().Add(59 * time.Minute)) {
+		t.Errorf("next run = %v, want an interval after the first fetch", status.NextRun)
+	}
+	if got := events.list(); len(got) != 1 || got[0].Behind != 1 {
+		t.Errorf("events = %+v, want one %s event", got, GitBehindEvent)
+	}
+}
+
+func TestResumeAutoFetch(t *testing.T) {
+	gm := newTestRepoManager(t)
+	config := GitFetchConfig{IntervalSeconds: 1800, Concurrency: 2}
+
+	// Nothing stored yet: start with the defaults
+	if status := gm.ResumeAutoFetch(); !status.Running || status.Config != DefaultGitFetchConfig {
+		t.Errorf("first status = %+v, want running with the default config", status)
+	}
+
+	// The last config is restored after a restart
+	gm.StartAutoFetch(config)
+	gm.StopAutoFetch()
+	if status := gm.ResumeAutoFetch(); !status.Running || status.Config != config {
+		t.Errorf("resumed status = %+v, want running with %+v", status, config)
+	}
+
+	// A scheduler the user stopped stays stopped
+	gm.DisableAutoFetch()
+	if status := gm.ResumeAutoFetch(); status.Running {
+		t.Errorf("status = %+v, want the disabled scheduler not to start", status)
+	}
+	gm.StopAutoFetch()
+}
+
+func TestGitFetchStopsHelpersOnTimeout(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("reads /proc")
+	}
+	newTestRepoManager(t)
+	dir := initTestRepo(t)
+	// An ssh command that records its PID and never answers
+	pidFile := filepath.Join(t.TempDir(), "ssh.pid")
+	gitTest(t, dir, "remote", "add", "origin", "ssh://example.invalid/repo.git")
+	gitTest(t, dir, "config", "core.sshCommand", fmt.Sprintf("sh -c 'echo $$ > %s; exec sleep 30'", pidFile))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := gitFetch(ctx, dir)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("fetch returned after %s, want soon after the timeout", elapsed)
+	}
+	wantGitError(t, err, GitErrCommand)
+
+	pid := strings.TrimSpace(readTestFile(t, filepath.Dir(pidFile), filepath.Base(pidFile)))
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		// Killed processes are gone, or zombies until init reaps them
+		stat, err := os.ReadFile(filepath.Join("/proc", pid, "stat"))
+		if err != nil || strings.Contains(string(stat), ") Z ") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ssh process %s is still running after the fetch timed out", pid)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,425 @@
+package devtools
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GitRepoGroup is a named set of repositories
+type GitRepoGroup struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	RepoIDs     []string `json:"repoIds"`
+}
+
+// Sort orders of GitRepoQuery
+const (
+	RepoSortName       = "name"
+	RepoSortLastCommit = "lastCommit"
+	RepoSortChanges    = "changes"
+)
+
+// GitRepoQuery filters and sorts repositories. Empty fields match everything.
+type GitRepoQuery struct {
+	Tags       []string `json:"tags,omitempty"`   // repositories must have all of these tags
+	Group      string   `json:"group,omitempty"`  // group ID
+	Status     string   `json:"status,omitempty"` // e.g. clean or modified
+	Branch     string   `json:"branch,omitempty"` // glob pattern, e.g. release/*
+	Text       string   `json:"text,omitempty"`   // matches name, path or description
+	SortBy     string   `json:"sortBy,omitempty"` // name, lastCommit or changes; defaults to name
+	Descending bool     `json:"descending,omitempty"`
+}
+
+// initGroupTables creates the tables for repository tags and groups
+func (gm *GitRepoManager) initGroupTables() {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS git_repo_tags (
+			repo_id TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (repo_id, tag)
+		)`,
+		`CREATE TABLE IF NOT EXISTS git_repo_groups (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			description TEXT
+		)`,
+		`CREATE TABLE IF NOT EXISTS git_repo_group_members (
+			group_id TEXT NOT NULL,
+			repo_id TEXT NOT NULL,
+			PRIMARY KEY (group_id, repo_id)
+		)`,
+	}
+	for _, statement := range statements {
+		if _, err := gm.db.Exec(statement); err != nil {
+			fmt.Printf("Error creating repository group tables: %v\n", err)
+		}
+	}
+}
+
+// loadTagsAndGroups loads repository tags and groups from the database. The
+// caller must hold gm.mutex.
+func (gm *GitRepoManager) loadTagsAndGroups() {
+	gm.groups = make(map[string]*GitRepoGroup)
+
+	rows, err := gm.db.Query("SELECT repo_id, tag FROM git_repo_tags ORDER BY tag")
+	if err != nil {
+		fmt.Printf("Error querying git_repo_tags: %v\n", err)
+	} else {
+		for rows.Next() {
+			var repoID, tag string
+			if err := rows.Scan(&repoID, &tag); err != nil {
+				fmt.Printf("Error scanning git_repo_tags row: %v\n", err)
+				continue
+			}
+			if repo, exists := gm.repos[repoID]; exists {
+				repo.Tags = append(repo.Tags, tag)
+			}
+		}
+		rows.Close()
+	}
+
+	rows, err = gm.db.Query("SELECT id, name, description FROM git_repo_groups")
+	if err != nil {
+		fmt.Printf("Error querying git_repo_groups: %v\n", err)
+		return
+	}
+	for rows.Next() {
+		group := &GitRepoGroup{RepoIDs: []string{}}
+		var description *string
+		if err := rows.Scan(&group.ID, &group.Name, &description); err != nil {
+			fmt.Printf("Error scanning git_repo_groups row: %v\n", err)
+			continue
+		}
+		if description != nil {
+			group.Description = *description
+		}
+		gm.groups[group.ID] = group
+	}
+	rows.Close()
+
+	rows, err = gm.db.Query("SELECT group_id, repo_id FROM git_repo_group_members")
+	if err != nil {
+		fmt.Printf("Error querying git_repo_group_members: %v\n", err)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var groupID, repoID string
+		if err := rows.Scan(&groupID, &repoID); err != nil {
+			fmt.Printf("Error scanning git_repo_group_members row: %v\n", err)
+			continue
+		}
+		group, groupExists := gm.groups[groupID]
+		repo, repoExists := gm.repos[repoID]
+		if groupExists && repoExists {
+			group.RepoIDs = append(group.RepoIDs, repoID)
+			repo.Groups = append(repo.Groups, groupID)
+		}
+	}
+}
+
+// SetRepoTags replaces the tags of a repository
+func (gm *GitRepoManager) SetRepoTags(repoID string, tags []string) (GitRepoInfo, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	repo, exists := gm.repos[repoID]
+	if !exists {
+		return GitRepoInfo{}, fmt.Errorf("repository with ID %s not found", repoID)
+	}
+	if gm.db == nil {
+		return GitRepoInfo{}, fmt.Errorf("database not initialized")
+	}
+	tags = normalizeTags(tags)
+
+	tx, err := gm.db.Begin()
+	if err != nil {
+		return GitRepoInfo{}, fmt.Errorf("error saving tags: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM git_repo_tags WHERE repo_id = ?", repoID); err != nil {
+		return GitRepoInfo{}, fmt.Errorf("error saving tags: %v", err)
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT INTO git_repo_tags (repo_id, tag) VALUES (?, ?)", repoID, tag); err != nil {
+			return GitRepoInfo{}, fmt.Errorf("error saving tags: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return GitRepoInfo{}, fmt.Errorf("error saving tags: %v", err)
+	}
+
+	repo.Tags = tags
+	return *repo, nil
+}
+
+// GetRepoTags returns every tag in use, sorted
+func (gm *GitRepoManager) GetRepoTags() []string {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, repo := range gm.repos {
+		for _, tag := range repo.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// GetRepoGroups returns all repository groups sorted by name
+func (gm *GitRepoManager) GetRepoGroups() []GitRepoGroup {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	groups := make([]GitRepoGroup, 0, len(gm.groups))
+	for _, group := range gm.groups {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.ToLower(groups[i].Name) < strings.ToLower(groups[j].Name)
+	})
+	return groups
+}
+
+// CreateRepoGroup creates an empty repository group
+func (gm *GitRepoManager) CreateRepoGroup(name, description string) (GitRepoGroup, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return GitRepoGroup{}, fmt.Errorf("group name is required")
+	}
+	if gm.db == nil {
+		return GitRepoGroup{}, fmt.Errorf("database not initialized")
+	}
+	for _, group := range gm.groups {
+		if strings.EqualFold(group.Name, name) {
+			return GitRepoGroup{}, fmt.Errorf("group %s already exists", name)
+		}
+	}
+
+	group := &GitRepoGroup{
+		ID:          fmt.Sprintf("group-%d", time.Now().UnixNano()),
+		Name:        name,
+		Description: description,
+		RepoIDs:     []string{},
+	}
+	if _, err := gm.db.Exec("INSERT INTO git_repo_groups (id, name, description) VALUES (?, ?, ?)", group.ID, group.Name, group.Description); err != nil {
+		return GitRepoGroup{}, fmt.Errorf("error saving group: %v", err)
+	}
+	if gm.groups == nil {
+		gm.groups = make(map[string]*GitRepoGroup)
+	}
+	gm.groups[group.ID] = group
+	return *group, nil
+}
+
+// DeleteRepoGroup deletes a repository group; its repositories are kept
+func (gm *GitRepoManager) DeleteRepoGroup(groupID string) error {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	group, exists := gm.groups[groupID]
+	if !exists {
+		return fmt.Errorf("group with ID %s not found", groupID)
+	}
+	if gm.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if _, err := gm.db.Exec("DELETE FROM git_repo_group_members WHERE group_id = ?", groupID); err != nil {
+		return fmt.Errorf("error deleting group: %v", err)
+	}
+	if _, err := gm.db.Exec("DELETE FROM git_repo_groups WHERE id = ?", groupID); err != nil {
+		return fmt.Errorf("error deleting group: %v", err)
+	}
+
+	for _, repoID := range group.RepoIDs {
+		if repo, exists := gm.repos[repoID]; exists {
+			repo.Groups = removeString(repo.Groups, groupID)
+		}
+	}
+	delete(gm.groups, groupID)
+	return nil
+}
+
+// AddReposToGroup adds repositories to a group
+func (gm *GitRepoManager) AddReposToGroup(groupID string, repoIDs []string) (GitRepoGroup, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	group, exists := gm.groups[groupID]
+	if !exists {
+		return GitRepoGroup{}, fmt.Errorf("group with ID %s not found", groupID)
+	}
+	if gm.db == nil {
+		return GitRepoGroup{}, fmt.Errorf("database not initialized")
+	}
+
+	for _, repoID := range repoIDs {
+		repo, exists := gm.repos[repoID]
+		if !exists {
+			return *group, fmt.Errorf("repository with ID %s not found", repoID)
+		}
+		if containsString(group.RepoIDs, repoID) {
+			continue
+		}
+		if _, err := gm.db.Exec("INSERT INTO git_repo_group_members (group_id, repo_id) VALUES (?, ?)", groupID, repoID); err != nil {
+			return *group, fmt.Errorf("error saving group: %v", err)
+		}
+		group.RepoIDs = append(group.RepoIDs, repoID)
+		repo.Groups = append(append([]string{}, repo.Groups...), groupID)
+	}
+	return *group, nil
+}
+
+// RemoveReposFromGroup removes repositories from a group
+func (gm *GitRepoManager) RemoveReposFromGroup(groupID string, repoIDs []string) (GitRepoGroup, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	group, exists := gm.groups[groupID]
+	if !exists {
+		return GitRepoGroup{}, fmt.Errorf("group with ID %s not found", groupID)
+	}
+	if gm.db == nil {
+		return GitRepoGroup{}, fmt.Errorf("database not initialized")
+	}
+
+	for _, repoID := range repoIDs {
+		if _, err := gm.db.Exec("DELETE FROM git_repo_group_members WHERE group_id = ? AND repo_id = ?", groupID, repoID); err != nil {
+			return *group, fmt.Errorf("error saving group: %v", err)
+		}
+		group.RepoIDs = removeString(group.RepoIDs, repoID)
+		if repo, exists := gm.repos[repoID]; exists {
+			repo.Groups = removeString(repo.Groups, groupID)
+		}
+	}
+	return *group, nil
+}
+
+// removeRepoMemberships deletes the tags and group memberships of a removed
+// repository. The caller must hold gm.mutex.
+func (gm *GitRepoManager) removeRepoMemberships(repoID string) {
+	for _, group := range gm.groups {
+		group.RepoIDs = removeString(group.RepoIDs, repoID)
+	}
+	if gm.db == nil {
+		return
+	}
+	if _, err := gm.db.Exec("DELETE FROM git_repo_tags WHERE repo_id = ?", repoID); err != nil {
+		fmt.Printf("Error deleting tags of repository %s: %v\n", repoID, err)
+	}
+	if _, err := gm.db.Exec("DELETE FROM git_repo_group_members WHERE repo_id = ?", repoID); err != nil {
+		fmt.Printf("Error deleting group memberships of repository %s: %v\n", repoID, err)
+	}
+}
+
+// QueryRepos returns the repositories matching a query, sorted as requested
+func (gm *GitRepoManager) QueryRepos(query GitRepoQuery) ([]GitRepoInfo, error) {
+	if query.Branch != "" {
+		if _, err := path.Match(query.Branch, ""); err != nil {
+			return nil, fmt.Errorf("invalid branch pattern: %s", query.Branch)
+		}
+	}
+	wantTags := normalizeTags(query.Tags)
+	text := strings.ToLower(strings.TrimSpace(query.Text))
+
+	repos := []GitRepoInfo{}
+	for _, repo := range gm.GetAllRepos() {
+		if query.Group != "" && !containsString(repo.Groups, query.Group) {
+			continue
+		}
+		if query.Status != "" && !strings.EqualFold(repo.Status, query.Status) {
+			continue
+		}
+		if query.Branch != "" {
+			if matched, _ := path.Match(query.Branch, repo.Branch); !matched {
+				continue
+			}
+		}
+		if text != "" &&
+			!strings.Contains(strings.ToLower(repo.Name), text) &&
+			!strings.Contains(strings.ToLower(repo.Path), text) &&
+			!strings.Contains(strings.ToLower(repo.Description), text) {
+			continue
+		}
+		hasTags := true
+		for _, tag := range wantTags {
+			if !containsString(repo.Tags, tag) {
+				hasTags = false
+				break
+			}
+		}
+		if !hasTags {
+			continue
+		}
+		repos = append(repos, repo)
+	}
+
+	var less func(a, b GitRepoInfo) bool
+	switch query.SortBy {
+	case RepoSortLastCommit:
+		less = func(a, b GitRepoInfo) bool { return a.LastUpdated.Before(b.LastUpdated) }
+	case RepoSortChanges:
+		less = func(a, b GitRepoInfo) bool { return a.Changes < b.Changes }
+	case "", RepoSortName:
+		less = func(a, b GitRepoInfo) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	default:
+		return nil, fmt.Errorf("unknown sort order: %s", query.SortBy)
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		if query.Descending {
+			return less(repos[j], repos[i])
+		}
+		return less(repos[i], repos[j])
+	})
+
+	return repos, nil
+}
+
+// normalizeTags trims, lowercases, deduplicates and sorts tags
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns a copy of values without value
+func removeString(values []string, value string) []string {
+	result := []string{}
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
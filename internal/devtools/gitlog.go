@@ -0,0 +1,246 @@
+package devtools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitCommitFormat prints the fields of a GitCommit separated by unit separators,
+// with each commit starting with a record separator, so that subjects and
+// bodies can contain any printable text
+const gitCommitFormat = "--format=%x1e%H%x1f%h%x1f%P%x1f%an%x1f%ae%x1f%aI%x1f%D%x1f%s%x1f%b"
+
+// defaultLogLimit is the page size used when GitLogOptions.Limit is not set
+const defaultLogLimit = 50
+
+// GitCommit represents a commit in a repository's history
+type GitCommit struct {
+	Hash        string    `json:"hash"`
+	ShortHash   string    `json:"shortHash"`
+	Parents     []string  `json:"parents"`
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"authorEmail"`
+	Date        time.Time `json:"date"`
+	Subject     string    `json:"subject"`
+	Body        string    `json:"body,omitempty"`
+	Refs        []string  `json:"refs,omitempty"`
+}
+
+// GitLogOptions filters and paginates a commit log
+type GitLogOptions struct {
+	Branch string `json:"branch,omitempty"` // ref to list, defaults to HEAD
+	All    bool   `json:"all,omitempty"`    // list commits of all refs instead of Branch
+	Author string `json:"author,omitempty"` // matches author name or email
+	Path   string `json:"path,omitempty"`   // only commits touching this path
+	Since  string `json:"since,omitempty"`  // any date git understands, e.g. 2024-01-31
+	Until  string `json:"until,omitempty"`
+	Skip   int    `json:"skip"`
+	Limit  int    `json:"limit"`
+}
+
+// GitLogPage is one page of a commit log
+type GitLogPage struct {
+	Commits  []GitCommit `json:"commits"`
+	HasMore  bool        `json:"hasMore"`
+	NextSkip int         `json:"nextSkip"`
+}
+
+// GitFileStat describes a file changed by a commit
+type GitFileStat struct {
+	Path      string `json:"path"`
+	OldPath   string `json:"oldPath,omitempty"` // set for renames and copies
+	Status    string `json:"status"`            // A, M, D, R, C or T
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary"`
+}
+
+// GitCommitDetail is a commit with the files it changed
+type GitCommitDetail struct {
+	GitCommit
+	Files     []GitFileStat `json:"files"`
+	Additions int           `json:"additions"`
+	Deletions int           `json:"deletions"`
+}
+
+// GetCommitLog returns a page of the commit history of a repository
+func (gm *GitRepoManager) GetCommitLog(repoID string, options GitLogOptions) (GitLogPage, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return GitLogPage{}, err
+	}
+
+	if options.Limit <= 0 {
+		options.Limit = defaultLogLimit
+	}
+	if options.Skip < 0 {
+		options.Skip = 0
+	}
+
+	// Fetch one extra commit to know whether there is another page
+	args := []string{"log", gitCommitFormat, "--date-order",
+		fmt.Sprintf("--skip=%d", options.Skip),
+		fmt.Sprintf("--max-count=%d", options.Limit+1),
+	}
+	if options.Author != "" {
+		args = append(args, "--author="+options.Author, "--regexp-ignore-case")
+	}
+	if options.Since != "" {
+		args = append(args, "--since="+options.Since)
+	}
+	if options.Until != "" {
+		args = append(args, "--until="+options.Until)
+	}
+	if options.All {
+		args = append(args, "--all")
+	}
+	args = append(args, "--end-of-options")
+	if !options.All && options.Branch != "" {
+		args = append(args, options.Branch)
+	}
+	args = append(args, "--")
+	if options.Path != "" {
+		args = append(args, options.Path)
+	}
+
+	output, err := runGit(path, args...)
+	if err != nil {
+		return GitLogPage{}, err
+	}
+
+	commits := parseCommits(output)
+	page := GitLogPage{Commits: commits}
+	if len(commits) > options.Limit {
+		page.Commits = commits[:options.Limit]
+		page.HasMore = true
+	}
+	page.NextSkip = options.Skip + len(page.Commits)
+	return page, nil
+}
+
+// GetCommitDetail returns a commit with per-file additions and deletions.
+// Merge commits are compared to their first parent.
+func (gm *GitRepoManager) GetCommitDetail(repoID, hash string) (GitCommitDetail, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return GitCommitDetail{}, err
+	}
+
+	output, err := runGit(path, "log", "-1", gitCommitFormat, "--end-of-options", hash, "--")
+	if err != nil {
+		return GitCommitDetail{}, err
+	}
+	commits := parseCommits(output)
+	if len(commits) == 0 {
+		return GitCommitDetail{}, fmt.Errorf("commit %s not found", hash)
+	}
+	detail := GitCommitDetail{GitCommit: commits[0], Files: []GitFileStat{}}
+
+	// Get the status of each file, then the line counts
+	diffArgs := []string{"show", "--format=", "-z", "-M", "--diff-merges=first-parent"}
+	statusOutput, err := runGit(path, append(append(diffArgs, "--name-status", "--end-of-options"), detail.Hash)...)
+	if err != nil {
+		return GitCommitDetail{}, err
+	}
+	numstatOutput, err := runGit(path, append(append(diffArgs, "--numstat", "--end-of-options"), detail.Hash)...)
+	if err != nil {
+		return GitCommitDetail{}, err
+	}
+
+	detail.Files = parseNameStatus(statusOutput)
+	stats := parseNumstat(numstatOutput)
+	for i := range detail.Files {
+		if stat, ok := stats[detail.Files[i].Path]; ok {
+			detail.Files[i].Additions = stat.Additions
+			detail.Files[i].Deletions = stat.Deletions
+			detail.Files[i].Binary = stat.Binary
+		}
+		detail.Additions += detail.Files[i].Additions
+		detail.Deletions += detail.Files[i].Deletions
+	}
+
+	return detail, nil
+}
+
+// parseCommits parses git log output produced with gitCommitFormat
+func parseCommits(output string) []GitCommit {
+	commits := []GitCommit{}
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.Split(record, "\x1f")
+		if len(fields) < 9 {
+			continue
+		}
+
+		commit := GitCommit{
+			Hash:        fields[0],
+			ShortHash:   fields[1],
+			Parents:     strings.Fields(fields[2]),
+			Author:      fields[3],
+			AuthorEmail: fields[4],
+			Subject:     fields[7],
+			Body:        strings.TrimSpace(strings.Join(fields[8:], "\x1f")),
+		}
+		if date, err := time.Parse(time.RFC3339, fields[5]); err == nil {
+			commit.Date = date
+		}
+		if fields[6] != "" {
+			commit.Refs = strings.Split(fields[6], ", ")
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+// parseNameStatus parses the output of git diff --name-status -z
+func parseNameStatus(output string) []GitFileStat {
+	files := []GitFileStat{}
+	tokens := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i] == "" || i+1 >= len(tokens) {
+			continue
+		}
+
+		file := GitFileStat{Status: tokens[i][:1]}
+		if (file.Status == "R" || file.Status == "C") && i+2 < len(tokens) {
+			file.OldPath = tokens[i+1]
+			file.Path = tokens[i+2]
+			i += 2
+		} else {
+			file.Path = tokens[i+1]
+			i++
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// parseNumstat parses the output of git diff --numstat -z, keyed by the new path
+func parseNumstat(output string) map[string]GitFileStat {
+	stats := make(map[string]GitFileStat)
+	tokens := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+	for i := 0; i < len(tokens); i++ {
+		parts := strings.SplitN(strings.TrimLeft(tokens[i], "\n"), "\t", 3)
+		if len(parts) < 3 {
+			continue
+		}
+
+		stat := GitFileStat{Path: parts[2]}
+		if parts[0] == "-" && parts[1] == "-" {
+			stat.Binary = true
+		} else {
+			stat.Additions, _ = strconv.Atoi(parts[0])
+			stat.Deletions, _ = strconv.Atoi(parts[1])
+		}
+
+		// Renames have an empty path followed by the old and new paths
+		if stat.Path == "" && i+2 < len(tokens) {
+			stat.OldPath = tokens[i+1]
+			stat.Path = tokens[i+2]
+			i += 2
+		}
+		stats[stat.Path] = stat
+	}
+	return stats
+}
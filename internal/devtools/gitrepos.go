@@ -1,6 +1,8 @@
 package devtools
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -15,17 +17,31 @@ import (
 
 // GitRepoInfo represents information about a Git repository
 type GitRepoInfo struct {
-	ID           string    `json:"id"`
-	Name         string    `json:"name"`
-	Path         string    `json:"path"`
-	Branch       string    `json:"branch"`
-	Status       string    `json:"status"`
-	LastCommit   string    `json:"lastCommit"`
-	LastCommitBy string    `json:"lastCommitBy"`
-	LastUpdated  time.Time `json:"lastUpdated"`
-	Changes      int       `json:"changes"`
-	URL          string    `json:"url,omitempty"`
-	Description  string    `json:"description,omitempty"`
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Path          string    `json:"path"`
+	Branch        string    `json:"branch"`
+	Status        string    `json:"status"`
+	LastCommit    string    `json:"lastCommit"`
+	LastCommitBy  string    `json:"lastCommitBy"`
+	LastUpdated   time.Time `json:"lastUpdated"`
+	Changes       int       `json:"changes"`
+	URL           string    `json:"url,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	Upstream      string    `json:"upstream,omitempty"`
+	Ahead         int       `json:"ahead"`
+	Behind        int       `json:"behind"`
+	StashCount    int       `json:"stashCount"`
+	Operation     string    `json:"operation,omitempty"` // merge, rebase, cherry-pick, revert or bisect in progress
+	Conflicts     int       `json:"conflicts"`
+	Staged        int       `json:"staged"`
+	Unstaged      int       `json:"unstaged"`
+	Untracked     int       `json:"untracked"`
+	FetchDisabled bool      `json:"fetchDisabled"`   // opts the repository out of background fetching
+	Error         string    `json:"error,omitempty"` // why the last refresh failed; not persisted
+	Tags          []string  `json:"tags,omitempty"`
+	Groups        []string  `json:"groups,omitempty"`   // IDs of the groups the repository belongs to
+	ParentID      string    `json:"parentId,omitempty"` // repository this one is a worktree of
 }
 
 // GitRepoManager manages Git repositories
@@ -35,8 +51,39 @@ type GitRepoManager struct {
 	configPath  string
 	db          *sql.DB
 	initialized bool
+	discards    map[string]pendingDiscard
+	emit        EventEmitter
+	fetcher     *gitFetcher
+	watching    bool
+	watches     map[string]*repoWatch
+	groups      map[string]*GitRepoGroup
 }
 
+// gitRepoColumns are the git_repos columns added after the table was first
+// created; migrateDB adds any that are missing
+var gitRepoColumns = []struct {
+	name       string
+	definition string
+}{
+	{"upstream", "TEXT DEFAULT ''"},
+	{"ahead", "INTEGER DEFAULT 0"},
+	{"behind", "INTEGER DEFAULT 0"},
+	{"stash_count", "INTEGER DEFAULT 0"},
+	{"operation", "TEXT DEFAULT ''"},
+	{"conflicts", "INTEGER DEFAULT 0"},
+	{"staged", "INTEGER DEFAULT 0"},
+	{"unstaged", "INTEGER DEFAULT 0"},
+	{"untracked", "INTEGER DEFAULT 0"},
+	{"fetch_disabled", "INTEGER DEFAULT 0"},
+	{"parent_id", "TEXT DEFAULT ''"},
+}
+
+// refreshWorkers is how many repositories RefreshAllRepos refreshes at the same time
+const refreshWorkers = 8
+
+// refreshCommandTimeout bounds each git command run to refresh a repository
+const refreshCommandTimeout = 15 * time.Second
+
 var (
 	gitManager     *GitRepoManager
 	gitManagerOnce sync.Once
@@ -60,7 +107,7 @@ func GetGitRepoManager() *GitRepoManager {
 		}
 
 		dbPath := filepath.Join(devexDir, "devex.db")
-		db, err := sql.Open("sqlite", dbPath)
+		db, err := openRepoDB(dbPath)
 		if err != nil {
 			fmt.Printf("Error opening database: %v\n", err)
 		}
@@ -81,6 +128,12 @@ func GetGitRepoManager() *GitRepoManager {
 	return gitManager
 }
 
+// openRepoDB opens the repository database. Connections wait for locks held by
+// other connections instead of failing with SQLITE_BUSY.
+func openRepoDB(path string) (*sql.DB, error) {
+	return sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
+}
+
 // initDB initializes the database schema
 func (gm *GitRepoManager) initDB() {
 	if gm.db == nil {
@@ -106,6 +159,41 @@ func (gm *GitRepoManager) initDB() {
 	if err != nil {
 		fmt.Printf("Error creating git_repos table: %v\n", err)
 	}
+
+	gm.migrateDB()
+	gm.initGroupTables()
+	gm.initSettingsTable()
+}
+
+// migrateDB adds the columns of gitRepoColumns that an older git_repos table lacks
+func (gm *GitRepoManager) migrateDB() {
+	rows, err := gm.db.Query("PRAGMA table_info(git_repos)")
+	if err != nil {
+		fmt.Printf("Error reading git_repos schema: %v\n", err)
+		return
+	}
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			fmt.Printf("Error scanning git_repos schema: %v\n", err)
+			continue
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, column := range gitRepoColumns {
+		if existing[column.name] {
+			continue
+		}
+		if _, err := gm.db.Exec(fmt.Sprintf("ALTER TABLE git_repos ADD COLUMN %s %s", column.name, column.definition)); err != nil {
+			fmt.Printf("Error adding column %s to git_repos: %v\n", column.name, err)
+		}
+	}
 }
 
 // loadReposFromDB loads repositories from the database
@@ -118,7 +206,9 @@ func (gm *GitRepoManager) loadReposFromDB() {
 	defer gm.mutex.Unlock()
 
 	rows, err := gm.db.Query(`
-		SELECT id, name, path, branch, status, last_commit, last_commit_by, last_updated, changes, url, description
+		SELECT id, name, path, branch, status, last_commit, last_commit_by, last_updated, changes, url, description,
+			upstream, ahead, behind, stash_count, operation, conflicts, staged, unstaged, untracked,
+			fetch_disabled, parent_id
 		FROM git_repos
 	`)
 	if err != nil {
@@ -143,6 +233,17 @@ func (gm *GitRepoManager) loadReposFromDB() {
 			&repo.Changes,
 			&repo.URL,
 			&repo.Description,
+			&repo.Upstream,
+			&repo.Ahead,
+			&repo.Behind,
+			&repo.StashCount,
+			&repo.Operation,
+			&repo.Conflicts,
+			&repo.Staged,
+			&repo.Unstaged,
+			&repo.Untracked,
+			&repo.FetchDisabled,
+			&repo.ParentID,
 		)
 		if err != nil {
 			fmt.Printf("Error scanning git_repo row: %v\n", err)
@@ -170,6 +271,8 @@ func (gm *GitRepoManager) loadReposFromDB() {
 		gm.addDefaultRepos()
 	}
 
+	gm.loadTagsAndGroups()
+
 	gm.initialized = true
 }
 
@@ -185,8 +288,10 @@ func (gm *GitRepoManager) saveRepoToDB(repo *GitRepoInfo) error {
 	// Insert or update the repository in the database
 	_, err := gm.db.Exec(`
 		INSERT OR REPLACE INTO git_repos (
-			id, name, path, branch, status, last_commit, last_commit_by, last_updated, changes, url, description
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			id, name, path, branch, status, last_commit, last_commit_by, last_updated, changes, url, description,
+			upstream, ahead, behind, stash_count, operation, conflicts, staged, unstaged, untracked,
+			fetch_disabled, parent_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		repo.ID,
 		repo.Name,
@@ -199,6 +304,17 @@ func (gm *GitRepoManager) saveRepoToDB(repo *GitRepoInfo) error {
 		repo.Changes,
 		repo.URL,
 		repo.Description,
+		repo.Upstream,
+		repo.Ahead,
+		repo.Behind,
+		repo.StashCount,
+		repo.Operation,
+		repo.Conflicts,
+		repo.Staged,
+		repo.Unstaged,
+		repo.Untracked,
+		repo.FetchDisabled,
+		repo.ParentID,
 	)
 	if err != nil {
 		return fmt.Errorf("error saving repository to database: %v", err)
@@ -281,6 +397,93 @@ func (gm *GitRepoManager) addDefaultRepos() {
 	gm.saveRepoToDB(nodeRepo)
 }
 
+// repoPath returns the filesystem path of a repository with ~ expanded
+func (gm *GitRepoManager) repoPath(repoID string) (string, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	repo, exists := gm.repos[repoID]
+	if !exists {
+		return "", fmt.Errorf("repository with ID %s not found", repoID)
+	}
+	return expandHome(repo.Path), nil
+}
+
+// Codes of GitError, so the frontend can react to specific failures
+const (
+	GitErrCommand         = "command_failed"
+	GitErrNotFound        = "not_found"
+	GitErrExists          = "already_exists"
+	GitErrInvalidName     = "invalid_name"
+	GitErrInvalidRef      = "invalid_ref"
+	GitErrDirty           = "dirty_worktree"
+	GitErrNotMerged       = "not_merged"
+	GitErrCurrentBranch   = "current_branch"
+	GitErrInvalidToken    = "invalid_token"
+	GitErrNothingToCommit = "nothing_to_commit"
+)
+
+// GitError is a failed Git operation with a machine-readable code
+type GitError struct {
+	Op      string `json:"op"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *GitError) Error() string {
+	return fmt.Sprintf("git %s: %s", e.Op, e.Message)
+}
+
+// runGit runs a git command in a repository and returns its output
+func runGit(path string, args ...string) (string, error) {
+	return runGitContext(context.Background(), path, args...)
+}
+
+// runGitContext runs a git command that is killed when ctx is done
+func runGitContext(ctx context.Context, path string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", path}, args...)...)
+	// Keep git status from rewriting the index, which would wake the file
+	// watcher, and treat paths as file names rather than glob patterns
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0", "GIT_LITERAL_PATHSPECS=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if ctx.Err() == context.DeadlineExceeded {
+			message = "timed out"
+		} else if message == "" {
+			message = err.Error()
+		}
+		return "", &GitError{Op: args[0], Code: GitErrCommand, Message: message}
+	}
+	return string(output), nil
+}
+
+// runGitInput runs a git command with input on its standard input
+func runGitInput(path, input string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", path}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_LITERAL_PATHSPECS=1")
+	cmd.Stdin = strings.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = strings.TrimSpace(string(output))
+		}
+		if message == "" {
+			message = err.Error()
+		}
+		return "", &GitError{Op: args[0], Code: GitErrCommand, Message: message}
+	}
+	return string(output), nil
+}
+
 // GetAllRepos returns all registered repositories
 func (gm *GitRepoManager) GetAllRepos() []GitRepoInfo {
 	gm.mutex.Lock()
@@ -293,23 +496,112 @@ func (gm *GitRepoManager) GetAllRepos() []GitRepoInfo {
 	return repos
 }
 
-// RefreshRepo refreshes the status of a repository
+// RefreshRepo refreshes the status of a repository. Git runs without holding
+// the manager's lock; the result is swapped in once it is complete.
 func (gm *GitRepoManager) RefreshRepo(repoID string) (GitRepoInfo, error) {
+	return gm.refreshRepo(context.Background(), repoID)
+}
+
+// RefreshAllRepos refreshes all repositories, refreshWorkers at a time, and
+// returns the updated list. Repositories that failed to refresh get the
+// "error" status and report the failure in their Error field.
+func (gm *GitRepoManager) RefreshAllRepos() []GitRepoInfo {
 	gm.mutex.Lock()
-	defer gm.mutex.Unlock()
+	repoIDs := make([]string, 0, len(gm.repos))
+	for id := range gm.repos {
+		repoIDs = append(repoIDs, id)
+	}
+	gm.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+	workers := refreshWorkers
+	if len(repoIDs) < workers {
+		workers = len(repoIDs)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				// Failures are recorded on the repository, so keep refreshing the others
+				gm.refreshRepo(context.Background(), id)
+			}
+		}()
+	}
+	for _, id := range repoIDs {
+		jobs <- id
+	}
+	close(jobs)
+	wg.Wait()
+
+	return gm.GetAllRepos()
+}
 
+// refreshRepo collects the status of a repository outside the lock and swaps it in
+func (gm *GitRepoManager) refreshRepo(ctx context.Context, repoID string) (GitRepoInfo, error) {
+	gm.mutex.Lock()
 	repo, exists := gm.repos[repoID]
 	if !exists {
+		gm.mutex.Unlock()
 		return GitRepoInfo{}, fmt.Errorf("repository with ID %s not found", repoID)
 	}
+	snapshot := *repo
+	gm.mutex.Unlock()
+
+	refreshed, err := collectRepoStatus(ctx, snapshot)
+
+	gm.mutex.Lock()
+	repo, exists = gm.repos[repoID]
+	if !exists {
+		gm.mutex.Unlock()
+		return GitRepoInfo{}, fmt.Errorf("repository with ID %s not found", repoID)
+	}
+	if err != nil {
+		repo.Status = "error"
+		repo.Error = err.Error()
+	} else {
+		// Only take the refreshed status so edits made meanwhile are kept
+		repo.Branch = refreshed.Branch
+		repo.Status = refreshed.Status
+		repo.LastCommit = refreshed.LastCommit
+		repo.LastCommitBy = refreshed.LastCommitBy
+		repo.LastUpdated = refreshed.LastUpdated
+		repo.Changes = refreshed.Changes
+		repo.Upstream = refreshed.Upstream
+		repo.Ahead = refreshed.Ahead
+		repo.Behind = refreshed.Behind
+		repo.StashCount = refreshed.StashCount
+		repo.Operation = refreshed.Operation
+		repo.Conflicts = refreshed.Conflicts
+		repo.Staged = refreshed.Staged
+		repo.Unstaged = refreshed.Unstaged
+		repo.Untracked = refreshed.Untracked
+		repo.Error = ""
+	}
+	result := *repo
+
+	// Save while holding the lock so writes are serialized and a concurrent
+	// edit can't be overwritten by this copy
+	if err == nil {
+		if saveErr := gm.saveRepoToDB(repo); saveErr != nil {
+			fmt.Printf("Error saving repository %s: %v\n", repoID, saveErr)
+		}
+	}
+	gm.mutex.Unlock()
 
+	return result, err
+}
+
+// collectRepoStatus reads the status of a repository with git. Each git
+// command is bounded by refreshCommandTimeout.
+func collectRepoStatus(ctx context.Context, repo GitRepoInfo) (GitRepoInfo, error) {
 	// Expand path if it contains ~
 	path := repo.Path
 	if strings.HasPrefix(path, "~/") {
 		home, err := os.UserHomeDir()
 		if err != nil {
-			repo.Status = "error"
-			return *repo, fmt.Errorf("error getting home directory: %v", err)
+			return repo, fmt.Errorf("error getting home directory: %v", err)
 		}
 		path = filepath.Join(home, path[2:])
 	}
@@ -317,86 +609,58 @@ func (gm *GitRepoManager) RefreshRepo(repoID string) (GitRepoInfo, error) {
 	// Check if the path exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		repo.Status = "not found"
-		gm.saveRepoToDB(repo)
-		return *repo, nil
+		return repo, nil
 	}
 
 	// Check if it's a Git repository
 	gitDir := filepath.Join(path, ".git")
 	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
 		repo.Status = "not a git repository"
-		gm.saveRepoToDB(repo)
-		return *repo, nil
+		return repo, nil
 	}
 
-	// Get the current branch
-	cmd := exec.Command("git", "-C", path, "branch", "--show-current")
-	output, err := cmd.CombinedOutput()
-	if err == nil {
-		branch := strings.TrimSpace(string(output))
-		if branch != "" {
-			repo.Branch = branch
-		}
+	// Get the branch, tracking state and changes in one pass
+	statusCtx, cancel := context.WithTimeout(ctx, refreshCommandTimeout)
+	status, err := readStatusContext(statusCtx, path)
+	cancel()
+	if err != nil {
+		return repo, err
+	}
+	repo.Branch = status.Branch
+	repo.Upstream = status.Upstream
+	repo.Ahead = status.Ahead
+	repo.Behind = status.Behind
+	repo.StashCount = status.Stashes
+	repo.Conflicts = status.Conflicts
+	repo.Staged = status.Staged
+	repo.Unstaged = status.Unstaged
+	repo.Untracked = status.Untracked
+	repo.Changes = status.Changes
+	if status.Changes == 0 {
+		repo.Status = "clean"
+	} else {
+		repo.Status = "modified"
 	}
 
-	// Get the last commit
-	cmd = exec.Command("git", "-C", path, "log", "-1", "--pretty=format:%s|%an|%ad", "--date=iso")
-	output, err = cmd.CombinedOutput()
+	// Get the last commit; a repository without commits has none
+	logCtx, cancel := context.WithTimeout(ctx, refreshCommandTimeout)
+	output, err := runGitContext(logCtx, path, "log", "-1", gitCommitFormat)
+	cancel()
 	if err == nil {
-		parts := strings.Split(strings.TrimSpace(string(output)), "|")
-		if len(parts) >= 3 {
-			repo.LastCommit = parts[0]
-			repo.LastCommitBy = parts[1]
-			// Parse the date if possible
-			if t, err := time.Parse("2006-01-02 15:04:05 -0700", parts[2]); err == nil {
-				repo.LastUpdated = t
+		if commits := parseCommits(output); len(commits) > 0 {
+			repo.LastCommit = commits[0].Subject
+			repo.LastCommitBy = commits[0].Author
+			if !commits[0].Date.IsZero() {
+				repo.LastUpdated = commits[0].Date
 			}
 		}
 	}
 
-	// Check if there are any changes
-	cmd = exec.Command("git", "-C", path, "status", "--porcelain")
-	output, err = cmd.CombinedOutput()
-	if err == nil {
-		changes := strings.TrimSpace(string(output))
-		if changes == "" {
-			repo.Status = "clean"
-			repo.Changes = 0
-		} else {
-			repo.Status = "modified"
-			repo.Changes = len(strings.Split(changes, "\n"))
-		}
-	} else {
-		repo.Status = "error"
-	}
-
-	// Update the repository info
-	gm.repos[repoID] = repo
+	opCtx, cancel := context.WithTimeout(ctx, refreshCommandTimeout)
+	repo.Operation = gitOperation(opCtx, path)
+	cancel()
 
-	// Save the updated repository to the database
-	gm.saveRepoToDB(repo)
-
-	return *repo, nil
-}
-
-// RefreshAllRepos refreshes all repositories and returns the updated list
-func (gm *GitRepoManager) RefreshAllRepos() []GitRepoInfo {
-	gm.mutex.Lock()
-	repoIDs := make([]string, 0, len(gm.repos))
-	for id := range gm.repos {
-		repoIDs = append(repoIDs, id)
-	}
-	gm.mutex.Unlock()
-
-	// Refresh each repository
-	for _, id := range repoIDs {
-		// We don't need to handle errors here, as we want to continue refreshing other repos
-		// even if one fails
-		gm.RefreshRepo(id)
-	}
-
-	// Return the updated list
-	return gm.GetAllRepos()
+	return repo, nil
 }
 
 // AddRepo adds a new repository
@@ -427,6 +691,9 @@ func (gm *GitRepoManager) AddRepo(repo GitRepoInfo) (GitRepoInfo, error) {
 
 	// Add the repository
 	gm.repos[repo.ID] = &repo
+	if gm.watching {
+		go gm.watchRepo(repo.ID)
+	}
 
 	// Save the repository to the database
 	if err := gm.saveRepoToDB(&repo); err != nil {
@@ -447,6 +714,8 @@ func (gm *GitRepoManager) RemoveRepo(repoID string) error {
 
 	// Remove the repository
 	delete(gm.repos, repoID)
+	gm.unwatchRepo(repoID)
+	gm.removeRepoMemberships(repoID)
 
 	// Delete the repository from the database
 	if err := gm.deleteRepoFromDB(repoID); err != nil {
@@ -456,45 +725,44 @@ func (gm *GitRepoManager) RemoveRepo(repoID string) error {
 	return nil
 }
 
-// GetRepoChanges returns the changes in a Git repository
-func (gm *GitRepoManager) GetRepoChanges(repoID string) ([]string, error) {
-	gm.mutex.Lock()
-	defer gm.mutex.Unlock()
-
-	repo, exists := gm.repos[repoID]
-	if !exists {
-		return nil, fmt.Errorf("repository with ID %s not found", repoID)
+// GetRepoChanges returns the changed files of a Git repository with their
+// line counts against HEAD
+func (gm *GitRepoManager) GetRepoChanges(repoID string) ([]GitFileChange, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Expand path if it contains ~
-	path := repo.Path
-	if strings.HasPrefix(path, "~/") {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("error getting home directory: %v", err)
-		}
-		path = filepath.Join(home, path[2:])
+	status, err := readStatus(path)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check if the path exists and is a Git repository
-	gitDir := filepath.Join(path, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("not a git repository or repository not found")
+	// Count lines of tracked files against HEAD, or the index before the first commit
+	args := []string{"diff", "HEAD", "--numstat", "-z", "-M", "--no-ext-diff"}
+	if _, err := runGit(path, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		args = []string{"diff", "--cached", "--numstat", "-z", "-M", "--no-ext-diff"}
 	}
-
-	// Get the changes
-	cmd := exec.Command("git", "-C", path, "status", "--porcelain")
-	output, err := cmd.CombinedOutput()
+	output, err := runGit(path, args...)
 	if err != nil {
-		return nil, fmt.Errorf("error getting git status: %v", err)
+		return nil, err
 	}
+	stats := parseNumstat(output)
 
-	changes := strings.TrimSpace(string(output))
-	if changes == "" {
-		return []string{}, nil
+	for i := range status.Files {
+		change := &status.Files[i]
+		if change.Untracked {
+			change.Additions, change.Binary = countFileLines(filepath.Join(path, change.Path))
+			continue
+		}
+		if stat, ok := stats[change.Path]; ok {
+			change.Additions = stat.Additions
+			change.Deletions = stat.Deletions
+			change.Binary = stat.Binary
+		}
 	}
 
-	return strings.Split(changes, "\n"), nil
+	return status.Files, nil
 }
 
 // Close closes the database connection
@@ -0,0 +1,92 @@
+package devtools
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newFileRepoManager returns a GitRepoManager backed by a database file that,
+// like the app's, is used through several connections
+func newFileRepoManager(t *testing.T) *GitRepoManager {
+	t.Helper()
+
+	newTestRepoManager(t)
+	db, err := openRepoDB(filepath.Join(t.TempDir(), "devex.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gm := &GitRepoManager{repos: make(map[string]*GitRepoInfo), db: db}
+	gm.initDB()
+	return gm
+}
+
+func TestOpenRepoDBWaitsForLocks(t *testing.T) {
+	gm := newFileRepoManager(t)
+
+	// Hold the write lock on one connection for a while
+	conn, err := gm.db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(context.Background(), "BEGIN IMMEDIATE"); err != nil {
+		t.Fatalf("BEGIN IMMEDIATE: %v", err)
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		conn.ExecContext(context.Background(), "COMMIT")
+	}()
+
+	if err := gm.saveSetting("test", "value"); err != nil {
+		t.Fatalf("saveSetting while another connection writes: %v", err)
+	}
+}
+
+func TestConcurrentRefreshesAndEditsAreSaved(t *testing.T) {
+	gm := newFileRepoManager(t)
+	var repoIDs []string
+	for i := 0; i < 4; i++ {
+		repoIDs = append(repoIDs, addTestRepo(t, gm, initTestRepo(t)))
+	}
+
+	// Refresh every repository while their auto-fetch setting changes
+	var wg sync.WaitGroup
+	for round := 0; round < 5; round++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gm.RefreshAllRepos()
+		}()
+		for _, id := range repoIDs {
+			wg.Add(1)
+			go func(id string, enabled bool) {
+				defer wg.Done()
+				if _, err := gm.SetAutoFetch(id, enabled); err != nil {
+					t.Errorf("SetAutoFetch: %v", err)
+				}
+			}(id, round%2 == 1)
+		}
+		wg.Wait()
+	}
+
+	// The last setting, and the refreshed status, were saved
+	reloaded := &GitRepoManager{repos: make(map[string]*GitRepoInfo), db: gm.db}
+	reloaded.loadReposFromDB()
+	for _, id := range repoIDs {
+		saved, exists := reloaded.repos[id]
+		if !exists {
+			t.Fatalf("repository %s was not saved", id)
+		}
+		if !saved.FetchDisabled {
+			t.Errorf("repository %s has auto-fetch enabled in the database, want the last setting", id)
+		}
+		if saved.Branch != "main" || saved.Status == "" {
+			t.Errorf("repository %s was saved without its status: %+v", id, saved)
+		}
+	}
+}
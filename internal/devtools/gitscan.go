@@ -0,0 +1,223 @@
+package devtools
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultScanDepth is the directory depth ScanForRepos searches when none is given
+const defaultScanDepth = 4
+
+// maxScanResults caps the repositories a single scan returns
+const maxScanResults = 1000
+
+// scanSkipDirs are directories that never contain repositories worth importing
+var scanSkipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	".git":         true,
+}
+
+// Kinds of GitRepoCandidate
+const (
+	RepoKindRepository = "repository"
+	RepoKindWorktree   = "worktree"
+	RepoKindSubmodule  = "submodule"
+)
+
+// GitRepoCandidate is a repository found by ScanForRepos
+type GitRepoCandidate struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"` // canonical path
+	URL        string `json:"url,omitempty"`
+	Kind       string `json:"kind"`
+	ExistingID string `json:"existingId,omitempty"` // set when the repository is already registered
+}
+
+// GitRepoImportResult reports the outcome of importing one candidate
+type GitRepoImportResult struct {
+	Path  string       `json:"path"`
+	Repo  *GitRepoInfo `json:"repo,omitempty"`
+	Error string       `json:"error,omitempty"`
+}
+
+// ScanForRepos walks root up to maxDepth directories deep and returns the Git
+// repositories, worktrees and submodules it finds
+func (gm *GitRepoManager) ScanForRepos(root string, maxDepth int) ([]GitRepoCandidate, error) {
+	root, err := canonicalPath(root)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", root, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	if maxDepth <= 0 {
+		maxDepth = defaultScanDepth
+	}
+
+	existing := gm.registeredPaths()
+	candidates := []GitRepoCandidate{}
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		// Unreadable directories are skipped rather than failing the scan
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if path != root && scanSkipDirs[entry.Name()] {
+			return filepath.SkipDir
+		}
+
+		if kind := repoKind(path); kind != "" {
+			candidate := GitRepoCandidate{Path: path, Kind: kind, ExistingID: existing[path]}
+			if output, err := runGit(path, "config", "--get", "remote.origin.url"); err == nil {
+				candidate.URL = strings.TrimSpace(output)
+			}
+			// Worktrees share their remote with the main repository, so use their directory name
+			if kind != RepoKindWorktree {
+				candidate.Name = repoNameFromURL(candidate.URL)
+			}
+			if candidate.Name == "" {
+				candidate.Name = filepath.Base(path)
+			}
+			candidates = append(candidates, candidate)
+			if len(candidates) >= maxScanResults {
+				return filepath.SkipAll
+			}
+		}
+
+		// Keep descending into repositories to find nested ones and submodules
+		rel, _ := filepath.Rel(root, path)
+		if rel != "." && strings.Count(rel, string(filepath.Separator))+1 >= maxDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error scanning %s: %v", root, err)
+	}
+
+	return candidates, nil
+}
+
+// AddScannedRepos registers the candidates of a scan and refreshes them.
+// Candidates whose canonical path is already registered, or that appear
+// twice, are reported as duplicates.
+func (gm *GitRepoManager) AddScannedRepos(candidates []GitRepoCandidate) []GitRepoImportResult {
+	existing := gm.registeredPaths()
+	results := make([]GitRepoImportResult, 0, len(candidates))
+
+	for i, candidate := range candidates {
+		result := GitRepoImportResult{Path: candidate.Path}
+		path, err := canonicalPath(candidate.Path)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Path = path
+		if id, exists := existing[path]; exists {
+			result.Error = fmt.Sprintf("repository already added with ID %s", id)
+			results = append(results, result)
+			continue
+		}
+
+		name := candidate.Name
+		if name == "" {
+			name = filepath.Base(path)
+		}
+		repo, err := gm.AddRepo(GitRepoInfo{
+			ID:   fmt.Sprintf("repo-%d-%d", time.Now().UnixNano(), i),
+			Name: name,
+			Path: path,
+			URL:  candidate.URL,
+		})
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		existing[path] = repo.ID
+
+		if refreshed, err := gm.RefreshRepo(repo.ID); err == nil {
+			repo = refreshed
+		}
+		result.Repo = &repo
+		results = append(results, result)
+	}
+	return results
+}
+
+// registeredPaths maps the canonical path of every registered repository to its ID
+func (gm *GitRepoManager) registeredPaths() map[string]string {
+	gm.mutex.Lock()
+	paths := make(map[string]string, len(gm.repos))
+	for id, repo := range gm.repos {
+		paths[repo.Path] = id
+	}
+	gm.mutex.Unlock()
+
+	canonical := make(map[string]string, len(paths))
+	for path, id := range paths {
+		if resolved, err := canonicalPath(path); err == nil {
+			canonical[resolved] = id
+		}
+	}
+	return canonical
+}
+
+// repoKind reports whether dir is the top of a repository, a linked worktree
+// or a submodule, or returns an empty string
+func repoKind(dir string) string {
+	info, err := os.Lstat(filepath.Join(dir, ".git"))
+	if err != nil {
+		return ""
+	}
+	if info.IsDir() {
+		return RepoKindRepository
+	}
+
+	// Worktrees and submodules have a .git file pointing at their git directory
+	data, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err != nil || !strings.HasPrefix(string(data), "gitdir:") {
+		return ""
+	}
+	gitDir := filepath.ToSlash(strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:")))
+	switch {
+	case strings.Contains(gitDir, "/worktrees/"):
+		return RepoKindWorktree
+	case strings.Contains(gitDir, "/modules/"):
+		return RepoKindSubmodule
+	default:
+		return RepoKindRepository
+	}
+}
+
+// repoNameFromURL returns the repository name of a remote URL such as
+// git@github.com:user/app.git or https://github.com/user/app
+func repoNameFromURL(url string) string {
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+	if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+		url = url[i+1:]
+	}
+	return url
+}
+
+// canonicalPath returns the absolute path of a file with ~ expanded and
+// symlinks resolved, so the same directory always gets the same path
+func canonicalPath(path string) (string, error) {
+	path, err := filepath.Abs(expandHome(path))
+	if err != nil {
+		return "", fmt.Errorf("error resolving path %s: %v", path, err)
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return filepath.Clean(path), nil
+}
@@ -0,0 +1,54 @@
+package devtools
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// initSettingsTable creates the table for Git settings that outlive the app
+func (gm *GitRepoManager) initSettingsTable() {
+	_, err := gm.db.Exec(`CREATE TABLE IF NOT EXISTS git_settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	)`)
+	if err != nil {
+		fmt.Printf("Error creating git_settings table: %v\n", err)
+	}
+}
+
+// loadSetting decodes the setting stored under key into value, leaving value
+// unchanged when it isn't stored. The caller must hold gm.mutex.
+func (gm *GitRepoManager) loadSetting(key string, value interface{}) {
+	if gm.db == nil {
+		return
+	}
+
+	var data string
+	err := gm.db.QueryRow("SELECT value FROM git_settings WHERE key = ?", key).Scan(&data)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			fmt.Printf("Error reading setting %s: %v\n", key, err)
+		}
+		return
+	}
+	if err := json.Unmarshal([]byte(data), value); err != nil {
+		fmt.Printf("Error parsing setting %s: %v\n", key, err)
+	}
+}
+
+// saveSetting stores value under key. The caller must hold gm.mutex.
+func (gm *GitRepoManager) saveSetting(key string, value interface{}) error {
+	if gm.db == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error encoding setting %s: %v", key, err)
+	}
+	if _, err := gm.db.Exec("INSERT OR REPLACE INTO git_settings (key, value) VALUES (?, ?)", key, string(data)); err != nil {
+		return fmt.Errorf("error saving setting %s: %v", key, err)
+	}
+	return nil
+}
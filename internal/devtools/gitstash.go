@@ -0,0 +1,246 @@
+package devtools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// gitStashFormat prints the fields of a GitStash for git stash list
+const gitStashFormat = "--format=%gd%x1f%H%x1f%gs%x1f%aI"
+
+// GitStash is an entry of a repository's stash
+type GitStash struct {
+	Index   int       `json:"index"`
+	Ref     string    `json:"ref"` // e.g. stash@{0}
+	Hash    string    `json:"hash"`
+	Message string    `json:"message"`
+	Branch  string    `json:"branch"` // branch the changes were stashed from
+	Date    time.Time `json:"date"`
+}
+
+// GitStashDetail is a stash entry with the files it changed and their diffs.
+// Untracked files stashed with it are listed in Untracked and diffed as added.
+type GitStashDetail struct {
+	GitStash
+	Files     []GitFileStat `json:"files"`
+	Diffs     []GitFileDiff `json:"diffs"`
+	Untracked []string      `json:"untracked"`
+}
+
+// ListStashes returns the stash entries of a repository, newest first
+func (gm *GitRepoManager) ListStashes(repoID string) ([]GitStash, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return nil, err
+	}
+	return listStashes(path)
+}
+
+// listStashes parses git stash list
+func listStashes(path string) ([]GitStash, error) {
+	output, err := runGit(path, "stash", "list", gitStashFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	stashes := []GitStash{}
+	for i, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) < 4 {
+			continue
+		}
+		stash := GitStash{Index: i, Ref: fields[0], Hash: fields[1], Message: fields[2]}
+
+		// Messages read "WIP on main: abc1234 subject" or "On main: message"
+		subject := strings.TrimPrefix(strings.TrimPrefix(fields[2], "WIP on "), "On ")
+		if branch, message, found := strings.Cut(subject, ": "); found {
+			stash.Branch = branch
+			stash.Message = message
+		}
+		if date, err := time.Parse(time.RFC3339, fields[3]); err == nil {
+			stash.Date = date
+		}
+		stashes = append(stashes, stash)
+	}
+	return stashes, nil
+}
+
+// findStash returns the stash entry with a commit hash. Stashes are identified
+// by hash because their index shifts whenever the stash changes.
+func findStash(path, hash string) (GitStash, error) {
+	stashes, err := listStashes(path)
+	if err != nil {
+		return GitStash{}, err
+	}
+	for _, stash := range stashes {
+		if stash.Hash == hash || (len(hash) >= 7 && strings.HasPrefix(stash.Hash, hash)) {
+			return stash, nil
+		}
+	}
+	return GitStash{}, &GitError{Op: "stash", Code: GitErrNotFound, Message: fmt.Sprintf("stash %s not found", hash)}
+}
+
+// ShowStash returns the files and diffs of a stash entry
+func (gm *GitRepoManager) ShowStash(repoID, hash string) (GitStashDetail, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return GitStashDetail{}, err
+	}
+	stash, err := findStash(path, hash)
+	if err != nil {
+		return GitStashDetail{}, err
+	}
+
+	detail := GitStashDetail{GitStash: stash, Untracked: []string{}}
+	detail.Files, detail.Diffs, err = diffTrees(path, stash.Hash+"^1", stash.Hash)
+	if err != nil {
+		return GitStashDetail{}, err
+	}
+
+	// Untracked files are kept in a third parent, diffed against an empty tree
+	if _, err := runGit(path, "rev-parse", "--verify", "--quiet", stash.Hash+"^3"); err == nil {
+		emptyTree, err := runGitInput(path, "", "hash-object", "-t", "tree", "--stdin")
+		if err != nil {
+			return GitStashDetail{}, err
+		}
+		files, diffs, err := diffTrees(path, strings.TrimSpace(emptyTree), stash.Hash+"^3")
+		if err != nil {
+			return GitStashDetail{}, err
+		}
+		for _, file := range files {
+			detail.Untracked = append(detail.Untracked, file.Path)
+		}
+		detail.Files = append(detail.Files, files...)
+		detail.Diffs = append(detail.Diffs, diffs...)
+	}
+
+	return detail, nil
+}
+
+// diffTrees returns the changed files between two commits or trees with their
+// line counts and parsed diffs
+func diffTrees(path, from, to string) ([]GitFileStat, []GitFileDiff, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff", "-M"}
+	statusOutput, err := runGit(path, append(args, "--name-status", "-z", from, to, "--")...)
+	if err != nil {
+		return nil, nil, err
+	}
+	numstatOutput, err := runGit(path, append(args, "--numstat", "-z", from, to, "--")...)
+	if err != nil {
+		return nil, nil, err
+	}
+	patch, err := runGit(path, append(args, from, to, "--")...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := parseNameStatus(statusOutput)
+	stats := parseNumstat(numstatOutput)
+	for i := range files {
+		if stat, ok := stats[files[i].Path]; ok {
+			files[i].Additions = stat.Additions
+			files[i].Deletions = stat.Deletions
+			files[i].Binary = stat.Binary
+		}
+	}
+
+	// The patch lists files in the same order as --name-status
+	diffs := []GitFileDiff{}
+	for i, chunk := range splitPatch(patch) {
+		diff := parseUnifiedDiff(chunk)
+		if i < len(files) {
+			diff.Path = files[i].Path
+			diff.OldPath = files[i].OldPath
+		}
+		diffs = append(diffs, diff)
+	}
+	return files, diffs, nil
+}
+
+// splitPatch splits a multi-file patch into one chunk per file
+func splitPatch(patch string) []string {
+	chunks := []string{}
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(patch, "\n") {
+		if strings.HasPrefix(line, "diff --git ") && current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// ApplyStash applies a stash entry and keeps it in the stash
+func (gm *GitRepoManager) ApplyStash(repoID, hash string) error {
+	return gm.runStash(repoID, hash, "apply")
+}
+
+// PopStash applies a stash entry and removes it. If applying conflicts the
+// entry is kept.
+func (gm *GitRepoManager) PopStash(repoID, hash string) error {
+	return gm.runStash(repoID, hash, "pop")
+}
+
+// DropStash removes a stash entry without applying it
+func (gm *GitRepoManager) DropStash(repoID, hash string) error {
+	return gm.runStash(repoID, hash, "drop")
+}
+
+// runStash runs git stash apply, pop or drop on the entry with a hash
+func (gm *GitRepoManager) runStash(repoID, hash, command string) error {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return err
+	}
+	stash, err := findStash(path, hash)
+	if err != nil {
+		return err
+	}
+
+	// Conflicts are reported on stdout, which runGitInput falls back to
+	_, err = runGitInput(path, "", "stash", command, stash.Ref)
+	gm.RefreshRepo(repoID)
+	return err
+}
+
+// CreateStash stashes the local changes of a repository, optionally with
+// untracked files, and returns the new entry
+func (gm *GitRepoManager) CreateStash(repoID, message string, includeUntracked bool) (GitStash, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return GitStash{}, err
+	}
+
+	before, err := runGit(path, "rev-parse", "--verify", "--quiet", "refs/stash")
+	if err != nil {
+		before = ""
+	}
+
+	args := []string{"stash", "push", "--quiet"}
+	if includeUntracked {
+		args = append(args, "--include-untracked")
+	}
+	if message = strings.TrimSpace(message); message != "" {
+		args = append(args, "--message", message)
+	}
+	if _, err := runGit(path, args...); err != nil {
+		return GitStash{}, err
+	}
+
+	// git stash push succeeds without creating an entry when there is nothing to stash
+	stashes, err := listStashes(path)
+	if err != nil {
+		return GitStash{}, err
+	}
+	if len(stashes) == 0 || stashes[0].Hash == strings.TrimSpace(before) {
+		return GitStash{}, &GitError{Op: "stash", Code: GitErrNothingToCommit, Message: "no local changes to stash"}
+	}
+
+	gm.RefreshRepo(repoID)
+	return stashes[0], nil
+}
@@ -0,0 +1,167 @@
+package devtools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// gitStatus summarises git status --porcelain=v2 --branch --show-stash
+type gitStatus struct {
+	Branch    string
+	Upstream  string
+	Ahead     int
+	Behind    int
+	Stashes   int
+	Staged    int
+	Unstaged  int
+	Untracked int
+	Conflicts int
+	Changes   int
+	Files     []GitFileChange
+}
+
+// gitOperations maps files in the git directory to the operation they indicate,
+// in the order they are checked
+var gitOperations = []struct {
+	file      string
+	operation string
+}{
+	{"rebase-merge", "rebase"},
+	{"rebase-apply", "rebase"},
+	{"MERGE_HEAD", "merge"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+	{"BISECT_LOG", "bisect"},
+}
+
+// readStatus reads the branch, tracking and change counts of a repository in one pass
+func readStatus(path string) (gitStatus, error) {
+	return readStatusContext(context.Background(), path)
+}
+
+// readStatusContext is readStatus with a context that can stop git
+func readStatusContext(ctx context.Context, path string) (gitStatus, error) {
+	output, err := runGitContext(ctx, path, "status", "--porcelain=v2", "--branch", "--show-stash", "-z")
+	if err != nil {
+		return gitStatus{}, err
+	}
+	return parseStatus(output), nil
+}
+
+// parseStatus parses the output of git status --porcelain=v2 --branch --show-stash -z
+func parseStatus(output string) gitStatus {
+	status := gitStatus{Files: []GitFileChange{}}
+	var oid string
+
+	tokens := strings.Split(output, "\x00")
+	for i := 0; i < len(tokens); i++ {
+		entry := tokens[i]
+		if entry == "" {
+			continue
+		}
+
+		switch entry[0] {
+		case '#':
+			fields := strings.Fields(entry)
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[1] {
+			case "branch.oid":
+				oid = fields[2]
+			case "branch.head":
+				status.Branch = fields[2]
+			case "branch.upstream":
+				status.Upstream = fields[2]
+			case "branch.ab":
+				if len(fields) >= 4 {
+					status.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+					status.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+				}
+			case "stash":
+				status.Stashes, _ = strconv.Atoi(fields[2])
+			}
+
+		case '1', '2':
+			// Renames and copies are followed by the original path
+			fieldCount := 9
+			if entry[0] == '2' {
+				fieldCount = 10
+			}
+			fields := strings.SplitN(entry, " ", fieldCount)
+			if len(fields) < fieldCount || len(fields[1]) != 2 {
+				continue
+			}
+			change := GitFileChange{
+				Path:           fields[fieldCount-1],
+				IndexStatus:    fields[1][:1],
+				WorktreeStatus: fields[1][1:],
+			}
+			if entry[0] == '2' && i+1 < len(tokens) {
+				i++
+				change.OrigPath = tokens[i]
+			}
+			if change.IndexStatus != "." {
+				status.Staged++
+			}
+			if change.WorktreeStatus != "." {
+				status.Unstaged++
+			}
+			status.Files = append(status.Files, change)
+			status.Changes++
+
+		case 'u':
+			fields := strings.SplitN(entry, " ", 11)
+			if len(fields) < 11 || len(fields[1]) != 2 {
+				continue
+			}
+			status.Files = append(status.Files, GitFileChange{
+				Path:           fields[10],
+				IndexStatus:    fields[1][:1],
+				WorktreeStatus: fields[1][1:],
+				Conflicted:     true,
+			})
+			status.Conflicts++
+			status.Changes++
+
+		case '?':
+			status.Files = append(status.Files, GitFileChange{
+				Path:           entry[2:],
+				IndexStatus:    "?",
+				WorktreeStatus: "?",
+				Untracked:      true,
+			})
+			status.Untracked++
+			status.Changes++
+		}
+	}
+
+	// Name a detached HEAD after the commit it points at
+	if status.Branch == "(detached)" {
+		if len(oid) > 7 {
+			oid = oid[:7]
+		}
+		status.Branch = "detached@" + oid
+	}
+	return status
+}
+
+// gitOperation returns the operation in progress in a repository (merge, rebase,
+// cherry-pick, revert or bisect), or an empty string
+func gitOperation(ctx context.Context, path string) string {
+	output, err := runGitContext(ctx, path, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return ""
+	}
+	gitDir := strings.TrimSpace(output)
+
+	for _, op := range gitOperations {
+		if _, err := os.Stat(filepath.Join(gitDir, op.file)); err == nil {
+			return op.operation
+		}
+	}
+	return ""
+}
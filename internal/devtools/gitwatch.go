@@ -0,0 +1,217 @@
+package devtools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// GitUpdatedEvent is the Wails event emitted with a repository's refreshed
+// GitRepoInfo after a change on disk
+const GitUpdatedEvent = "gitrepo:updated"
+
+// watchDebounce is how long changes are collected before a repository is refreshed
+const watchDebounce = 500 * time.Millisecond
+
+// maxRepoWatches caps the directories watched per repository, for huge monorepos
+const maxRepoWatches = 4096
+
+// repoWatch is a watched repository and the changes waiting to be handled
+type repoWatch struct {
+	closer  io.Closer
+	root    string
+	gitDir  string
+	pending map[string]bool
+	timer   *time.Timer
+}
+
+// StartWatching watches all registered repositories and refreshes a repository
+// shortly after its files or git state change
+func (gm *GitRepoManager) StartWatching() error {
+	if !watchSupported {
+		return fmt.Errorf("file watching is not supported on %s", runtime.GOOS)
+	}
+
+	gm.mutex.Lock()
+	if gm.watching {
+		gm.mutex.Unlock()
+		return nil
+	}
+	gm.watching = true
+	gm.watches = make(map[string]*repoWatch)
+	repoIDs := make([]string, 0, len(gm.repos))
+	for id := range gm.repos {
+		repoIDs = append(repoIDs, id)
+	}
+	gm.mutex.Unlock()
+
+	for _, id := range repoIDs {
+		go gm.watchRepo(id)
+	}
+	return nil
+}
+
+// StopWatching stops watching all repositories
+func (gm *GitRepoManager) StopWatching() {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	gm.watching = false
+	for id := range gm.watches {
+		gm.unwatchRepo(id)
+	}
+}
+
+// watchRepo starts watching a repository. Repositories that don't exist or
+// aren't Git repositories are skipped.
+func (gm *GitRepoManager) watchRepo(repoID string) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return
+	}
+	output, err := runGit(path, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return
+	}
+	gitDir := strings.TrimSpace(output)
+
+	// Directories ignored by .gitignore aren't watched. They are checked a
+	// whole level of the tree at a time, so a walk spawns one git per level.
+	ignored := func(dirs []string) map[string]bool {
+		rels := make(map[string]string, len(dirs))
+		paths := make([]string, 0, len(dirs))
+		for _, dir := range dirs {
+			rel, err := filepath.Rel(path, dir)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			rels[rel] = dir
+			paths = append(paths, rel)
+		}
+
+		result := make(map[string]bool)
+		if len(paths) == 0 {
+			return result
+		}
+		for rel := range checkIgnored(path, paths) {
+			if dir, exists := rels[rel]; exists {
+				result[dir] = true
+			}
+		}
+		return result
+	}
+
+	closer, err := watchRepoDir(path, gitDir, ignored, func(changed string) {
+		gm.repoChanged(repoID, changed)
+	})
+	if err != nil {
+		fmt.Printf("Error watching repository %s: %v\n", repoID, err)
+		return
+	}
+
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+	if _, exists := gm.repos[repoID]; !exists || !gm.watching || gm.watches[repoID] != nil {
+		closer.Close()
+		return
+	}
+	gm.watches[repoID] = &repoWatch{closer: closer, root: path, gitDir: gitDir, pending: make(map[string]bool)}
+}
+
+// unwatchRepo stops watching a repository. The caller must hold gm.mutex.
+func (gm *GitRepoManager) unwatchRepo(repoID string) {
+	watch, exists := gm.watches[repoID]
+	if !exists {
+		return
+	}
+	if watch.timer != nil {
+		watch.timer.Stop()
+	}
+	watch.closer.Close()
+	delete(gm.watches, repoID)
+}
+
+// repoChanged records a changed path and schedules a refresh of the repository
+func (gm *GitRepoManager) repoChanged(repoID, path string) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	watch, exists := gm.watches[repoID]
+	if !exists {
+		return
+	}
+	watch.pending[path] = true
+	if watch.timer == nil {
+		watch.timer = time.AfterFunc(watchDebounce, func() { gm.flushChanges(repoID, watch) })
+	}
+}
+
+// flushChanges refreshes a repository if any of its pending changes can affect
+// its status and pushes the result to the frontend
+func (gm *GitRepoManager) flushChanges(repoID string, watch *repoWatch) {
+	gm.mutex.Lock()
+	if gm.watches[repoID] != watch {
+		gm.mutex.Unlock()
+		return
+	}
+	pending := watch.pending
+	watch.pending = make(map[string]bool)
+	watch.timer = nil
+	emit := gm.emit
+	gm.mutex.Unlock()
+
+	// Changes to the git directory always count; worktree changes only when
+	// the file isn't ignored
+	relevant := false
+	var files []string
+	for path := range pending {
+		if path == "" || path == watch.gitDir || strings.HasPrefix(path, watch.gitDir+string(filepath.Separator)) {
+			relevant = true
+			break
+		}
+		if rel, err := filepath.Rel(watch.root, path); err == nil {
+			files = append(files, rel)
+		}
+	}
+	if !relevant && len(files) > 0 {
+		ignored := checkIgnored(watch.root, files)
+		relevant = len(ignored) < len(files)
+	}
+	if !relevant {
+		return
+	}
+
+	repo, err := gm.RefreshRepo(repoID)
+	if err != nil && repo.ID == "" {
+		return
+	}
+	if emit != nil {
+		emit(GitUpdatedEvent, repo)
+	}
+}
+
+// checkIgnored returns the paths that .gitignore rules exclude. git
+// check-ignore exits with 1 when no path is ignored.
+func checkIgnored(root string, paths []string) map[string]bool {
+	cmd := exec.Command("git", "-C", root, "check-ignore", "-z", "--stdin")
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+
+	ignored := make(map[string]bool)
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return ignored
+	}
+	for _, path := range bytes.Split(output, []byte{0}) {
+		if len(path) > 0 {
+			ignored[string(path)] = true
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,198 @@
+//go:build linux
+
+package devtools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// watchSupported reports whether repositories can be watched on this platform
+const watchSupported = true
+
+// inotifyMask selects the directory events that can change a repository's status
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_ONLYDIR
+
+// errWatchLimit stops adding watches once a repository reaches maxRepoWatches
+var errWatchLimit = errors.New("watch limit reached")
+
+// inotifyWatcher watches the worktree and git directory of a repository
+type inotifyWatcher struct {
+	file     *os.File
+	fd       int
+	root     string
+	gitDir   string
+	refsDir  string
+	ignored  func(dirs []string) map[string]bool // the ignored directories among dirs
+	onChange func(path string)
+	mutex    sync.Mutex
+	dirs     map[int]string // watch descriptor -> directory
+	limited  bool
+}
+
+// watchRepoDir watches the worktree at root, except ignored directories, plus
+// the top of gitDir and its refs. onChange is called with the path of every
+// change, or an empty path when events were lost.
+func watchRepoDir(root, gitDir string, ignored func(dirs []string) map[string]bool, onChange func(path string)) (io.Closer, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing inotify: %v", err)
+	}
+
+	w := &inotifyWatcher{
+		// A non-blocking descriptor lets Close interrupt a pending read
+		file:     os.NewFile(uintptr(fd), "inotify"),
+		fd:       fd,
+		root:     root,
+		gitDir:   gitDir,
+		refsDir:  filepath.Join(gitDir, "refs"),
+		ignored:  ignored,
+		onChange: onChange,
+		dirs:     make(map[int]string),
+	}
+
+	if err := w.addWatch(gitDir); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+	w.addTree(w.refsDir)
+	w.addTree(root)
+
+	go w.readEvents()
+	return w, nil
+}
+
+// Close stops watching
+func (w *inotifyWatcher) Close() error {
+	return w.file.Close()
+}
+
+// addWatch watches a single directory
+func (w *inotifyWatcher) addWatch(dir string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if len(w.dirs) >= maxRepoWatches {
+		if !w.limited {
+			w.limited = true
+			fmt.Printf("Watch limit of %d directories reached for %s; later changes may be missed\n", maxRepoWatches, w.root)
+		}
+		return errWatchLimit
+	}
+
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, inotifyMask)
+	if err != nil {
+		return fmt.Errorf("error watching %s: %v", dir, err)
+	}
+	w.dirs[wd] = dir
+	return nil
+}
+
+// addTree watches a directory and its subdirectories, skipping git
+// directories and ignored paths. The tree is walked a level at a time so that
+// the subdirectories of each level are checked against .gitignore together.
+func (w *inotifyWatcher) addTree(dir string) {
+	level := []string{dir}
+	for len(level) > 0 {
+		var children []string
+		for _, parent := range level {
+			if err := w.addWatch(parent); errors.Is(err, errWatchLimit) {
+				return
+			} else if err != nil {
+				continue
+			}
+			entries, err := os.ReadDir(parent)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				path := filepath.Join(parent, entry.Name())
+				if entry.IsDir() && entry.Name() != ".git" && path != w.gitDir {
+					children = append(children, path)
+				}
+			}
+		}
+
+		ignored := map[string]bool{}
+		if len(children) > 0 && !w.inGitDir(dir) {
+			ignored = w.ignored(children)
+		}
+		level = level[:0]
+		for _, child := range children {
+			if !ignored[child] {
+				level = append(level, child)
+			}
+		}
+	}
+}
+
+// inGitDir reports whether path is the git directory or inside it
+func (w *inotifyWatcher) inGitDir(path string) bool {
+	return path == w.gitDir || strings.HasPrefix(path, w.gitDir+string(filepath.Separator))
+}
+
+// readEvents reports events until the watcher is closed
+func (w *inotifyWatcher) readEvents() {
+	buffer := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buffer)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buffer[offset]))
+			nameBytes := buffer[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			name := string(bytes.TrimRight(nameBytes, "\x00"))
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			w.handleEvent(int(event.Wd), event.Mask, name)
+		}
+	}
+}
+
+// handleEvent reports one event and watches newly created directories
+func (w *inotifyWatcher) handleEvent(wd int, mask uint32, name string) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		w.onChange("")
+		return
+	}
+
+	w.mutex.Lock()
+	dir, exists := w.dirs[wd]
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(w.dirs, wd)
+	}
+	w.mutex.Unlock()
+	if !exists || name == "" {
+		return
+	}
+
+	// Lock files come and go around every write git makes
+	if strings.HasSuffix(name, ".lock") {
+		return
+	}
+
+	path := filepath.Join(dir, name)
+	if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		// Only refs are watched below the top of the git directory
+		insideGitDir := w.inGitDir(dir)
+		switch {
+		case insideGitDir && (path == w.refsDir || strings.HasPrefix(path, w.refsDir+string(filepath.Separator))):
+			w.addTree(path)
+		case !insideGitDir && name != ".git" && !w.ignored([]string{path})[path]:
+			w.addTree(path)
+		}
+	}
+
+	w.onChange(path)
+}
@@ -0,0 +1,105 @@
+//go:build linux
+
+package devtools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchRepoDirSkipsIgnoredDirectories(t *testing.T) {
+	newTestRepoManager(t)
+	repo := initTestRepo(t)
+	commitTestFile(t, repo, ".gitignore", "node_modules/\nbuild\n", "Ignore build output")
+	for _, dir := range []string{"src/app/build/cache", "src/lib", "node_modules/pkg/lib", "docs"} {
+		if err := os.MkdirAll(filepath.Join(repo, dir), 0755); err != nil {
+			t.Fatalf("create %s: %v", dir, err)
+		}
+	}
+
+	// Record the batches a real watch would check with git check-ignore
+	var batchMutex sync.Mutex
+	var batches [][]string
+	ignored := func(dirs []string) map[string]bool {
+		batchMutex.Lock()
+		batches = append(batches, dirs)
+		batchMutex.Unlock()
+		rels := make([]string, len(dirs))
+		for i, dir := range dirs {
+			rels[i], _ = filepath.Rel(repo, dir)
+		}
+		result := make(map[string]bool)
+		for rel := range checkIgnored(repo, rels) {
+			result[filepath.Join(repo, rel)] = true
+		}
+		return result
+	}
+
+	changes := make(chan string, 64)
+	closer, err := watchRepoDir(repo, filepath.Join(repo, ".git"), ignored, func(path string) { changes <- path })
+	if err != nil {
+		t.Fatalf("watchRepoDir: %v", err)
+	}
+	defer closer.Close()
+
+	w := closer.(*inotifyWatcher)
+	w.mutex.Lock()
+	watched := make(map[string]bool)
+	for _, dir := range w.dirs {
+		if rel, err := filepath.Rel(repo, dir); err == nil && !strings.HasPrefix(rel, ".git") {
+			watched[rel] = true
+		}
+	}
+	w.mutex.Unlock()
+
+	for _, dir := range []string{".", "src", "src/app", "src/lib", "docs"} {
+		if !watched[dir] {
+			t.Errorf("%s is not watched", dir)
+		}
+	}
+	for _, dir := range []string{"node_modules", "node_modules/pkg", "src/app/build", "src/app/build/cache"} {
+		if watched[dir] {
+			t.Errorf("ignored directory %s is watched", dir)
+		}
+	}
+	// One batch per level of the tree below the root
+	batchMutex.Lock()
+	if len(batches) != 3 {
+		t.Errorf("checked %d batches, want 3: %v", len(batches), batches)
+	}
+	batchMutex.Unlock()
+
+	// A new directory is checked on its own and watched unless ignored
+	if err := os.MkdirAll(filepath.Join(repo, "docs", "build"), 0755); err != nil {
+		t.Fatalf("create docs/build: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(repo, "docs", "guide"), 0755); err != nil {
+		t.Fatalf("create docs/guide: %v", err)
+	}
+	deadline := time.After(5 * time.Second)
+	for seen := 0; seen < 2; {
+		select {
+		case <-changes:
+			seen++
+		case <-deadline:
+			t.Fatal("no events for the new directories")
+		}
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	watched = make(map[string]bool)
+	for _, dir := range w.dirs {
+		watched[dir] = true
+	}
+	if !watched[filepath.Join(repo, "docs", "guide")] {
+		t.Errorf("new directory docs/guide is not watched")
+	}
+	if watched[filepath.Join(repo, "docs", "build")] {
+		t.Errorf("new ignored directory docs/build is watched")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package devtools
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// watchSupported reports whether repositories can be watched on this platform
+const watchSupported = false
+
+// watchRepoDir is not available on this platform
+func watchRepoDir(root, gitDir string, ignored func(dirs []string) map[string]bool, onChange func(path string)) (io.Closer, error) {
+	return nil, fmt.Errorf("file watching is not supported on %s", runtime.GOOS)
+}
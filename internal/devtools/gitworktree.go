@@ -0,0 +1,273 @@
+package devtools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GitWorktree is a working tree of a repository
+type GitWorktree struct {
+	Path           string `json:"path"`
+	Head           string `json:"head"`
+	Branch         string `json:"branch,omitempty"` // empty when detached
+	Detached       bool   `json:"detached"`
+	Bare           bool   `json:"bare"`
+	IsMain         bool   `json:"isMain"`
+	Locked         bool   `json:"locked"`
+	LockReason     string `json:"lockReason,omitempty"`
+	Prunable       bool   `json:"prunable"`
+	PrunableReason string `json:"prunableReason,omitempty"`
+	RepoID         string `json:"repoId,omitempty"` // set when the worktree is registered as a repository
+}
+
+// GitWorktreeOptions describes a worktree to create
+type GitWorktreeOptions struct {
+	Branch     string `json:"branch"`
+	StartPoint string `json:"startPoint,omitempty"` // for a new branch, defaults to HEAD
+	Path       string `json:"path,omitempty"`       // defaults to a sibling directory of the repository
+	Register   bool   `json:"register"`             // also track the worktree as a repository
+}
+
+// ListWorktrees returns the worktrees of a repository, the main one first
+func (gm *GitRepoManager) ListWorktrees(repoID string) ([]GitWorktree, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := runGit(path, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	worktrees := parseWorktrees(output)
+
+	registered := gm.registeredPaths()
+	for i := range worktrees {
+		if resolved, err := canonicalPath(worktrees[i].Path); err == nil {
+			worktrees[i].RepoID = registered[resolved]
+		}
+	}
+	return worktrees, nil
+}
+
+// parseWorktrees parses git worktree list --porcelain, where each worktree is
+// a block of lines ending with an empty line
+func parseWorktrees(output string) []GitWorktree {
+	worktrees := []GitWorktree{}
+	var current *GitWorktree
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "worktree":
+			worktrees = append(worktrees, GitWorktree{Path: value, IsMain: len(worktrees) == 0})
+			current = &worktrees[len(worktrees)-1]
+		case "HEAD":
+			if current != nil {
+				current.Head = value
+			}
+		case "branch":
+			if current != nil {
+				current.Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		case "detached":
+			if current != nil {
+				current.Detached = true
+			}
+		case "bare":
+			if current != nil {
+				current.Bare = true
+			}
+		case "locked":
+			if current != nil {
+				current.Locked = true
+				current.LockReason = value
+			}
+		case "prunable":
+			if current != nil {
+				current.Prunable = true
+				current.PrunableReason = value
+			}
+		}
+	}
+	return worktrees
+}
+
+// CreateWorktree checks out a branch in a new worktree. An existing local
+// branch is used as is, a remote branch of the same name gets a local
+// tracking branch, and otherwise a new branch is created from StartPoint.
+func (gm *GitRepoManager) CreateWorktree(repoID string, options GitWorktreeOptions) (GitWorktree, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return GitWorktree{}, err
+	}
+	branch := strings.TrimSpace(options.Branch)
+	if err := checkBranchName(path, branch); err != nil {
+		return GitWorktree{}, err
+	}
+	if strings.HasPrefix(options.StartPoint, "-") {
+		return GitWorktree{}, &GitError{Op: "worktree", Code: GitErrInvalidRef, Message: fmt.Sprintf("invalid start point: %s", options.StartPoint)}
+	}
+
+	// Default to a sibling directory such as ../app-feature-login
+	worktreePath := options.Path
+	if worktreePath == "" {
+		worktreePath = filepath.Join(filepath.Dir(path), filepath.Base(path)+"-"+strings.ReplaceAll(branch, "/", "-"))
+	}
+	worktreePath, err = filepath.Abs(expandHome(worktreePath))
+	if err != nil {
+		return GitWorktree{}, fmt.Errorf("error resolving path %s: %v", worktreePath, err)
+	}
+	if _, err := os.Stat(worktreePath); err == nil {
+		return GitWorktree{}, &GitError{Op: "worktree", Code: GitErrExists, Message: fmt.Sprintf("%s already exists", worktreePath)}
+	}
+
+	args := []string{"worktree", "add"}
+	if _, err := runGit(path, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		args = append(args, worktreePath, branch)
+	} else {
+		output, _ := runGit(path, "for-each-ref", "--format=%(refname:short)", "refs/remotes/*/"+branch)
+		if remotes := strings.Fields(output); len(remotes) > 0 && options.StartPoint == "" {
+			args = append(args, "--track", "-b", branch, worktreePath, remotes[0])
+		} else {
+			startPoint := options.StartPoint
+			if startPoint == "" {
+				startPoint = "HEAD"
+			}
+			args = append(args, "-b", branch, worktreePath, startPoint)
+		}
+	}
+	if _, err := runGit(path, args...); err != nil {
+		return GitWorktree{}, err
+	}
+
+	if options.Register {
+		gm.mutex.Lock()
+		parent, exists := gm.repos[repoID]
+		if !exists {
+			gm.mutex.Unlock()
+			return GitWorktree{}, fmt.Errorf("repository with ID %s not found", repoID)
+		}
+		info := *parent
+		gm.mutex.Unlock()
+
+		repo, err := gm.AddRepo(GitRepoInfo{
+			Name:        fmt.Sprintf("%s (%s)", info.Name, branch),
+			Path:        worktreePath,
+			URL:         info.URL,
+			Description: fmt.Sprintf("Worktree of %s", info.Name),
+			ParentID:    repoID,
+		})
+		if err != nil {
+			return GitWorktree{}, err
+		}
+		gm.RefreshRepo(repo.ID)
+	}
+
+	return gm.findWorktree(repoID, worktreePath)
+}
+
+// RemoveWorktree removes a linked worktree and unregisters it. Unless force is
+// set, worktrees with local changes, including untracked files, are refused.
+func (gm *GitRepoManager) RemoveWorktree(repoID, worktreePath string, force bool) error {
+	worktree, err := gm.findWorktree(repoID, worktreePath)
+	if err != nil {
+		return err
+	}
+	if worktree.IsMain {
+		return &GitError{Op: "worktree", Code: GitErrCurrentBranch, Message: "the main worktree can't be removed"}
+	}
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		if worktree.Locked {
+			return &GitError{Op: "worktree", Code: GitErrDirty, Message: fmt.Sprintf("worktree %s is locked", worktree.Path)}
+		}
+		if output, err := runGit(worktree.Path, "status", "--porcelain"); err == nil && strings.TrimSpace(output) != "" {
+			return &GitError{Op: "worktree", Code: GitErrDirty, Message: fmt.Sprintf("worktree %s has uncommitted changes", worktree.Path)}
+		}
+	}
+
+	args := []string{"worktree", "remove"}
+	if force {
+		// Twice to also remove locked worktrees
+		args = append(args, "--force", "--force")
+	}
+	if _, err := runGit(path, append(args, worktree.Path)...); err != nil {
+		return err
+	}
+
+	if worktree.RepoID != "" {
+		return gm.RemoveRepo(worktree.RepoID)
+	}
+	return nil
+}
+
+// PruneWorktrees deletes the records of worktrees whose directory is gone,
+// unregisters the repositories linked to them and returns the remaining worktrees
+func (gm *GitRepoManager) PruneWorktrees(repoID string) ([]GitWorktree, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := runGit(path, "worktree", "prune"); err != nil {
+		return nil, err
+	}
+
+	worktrees, err := gm.ListWorktrees(repoID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := make(map[string]bool, len(worktrees))
+	for _, worktree := range worktrees {
+		if worktree.RepoID != "" {
+			remaining[worktree.RepoID] = true
+		}
+	}
+
+	// Registered worktrees of this repository that no longer exist
+	gm.mutex.Lock()
+	var stale []string
+	for id, repo := range gm.repos {
+		if repo.ParentID == repoID && !remaining[id] {
+			if _, err := os.Stat(expandHome(repo.Path)); os.IsNotExist(err) {
+				stale = append(stale, id)
+			}
+		}
+	}
+	gm.mutex.Unlock()
+	for _, id := range stale {
+		gm.RemoveRepo(id)
+	}
+
+	return worktrees, nil
+}
+
+// findWorktree returns the worktree of a repository at a path
+func (gm *GitRepoManager) findWorktree(repoID, worktreePath string) (GitWorktree, error) {
+	worktrees, err := gm.ListWorktrees(repoID)
+	if err != nil {
+		return GitWorktree{}, err
+	}
+	want := worktreeKey(worktreePath)
+	for _, worktree := range worktrees {
+		if worktreeKey(worktree.Path) == want {
+			return worktree, nil
+		}
+	}
+	return GitWorktree{}, &GitError{Op: "worktree", Code: GitErrNotFound, Message: fmt.Sprintf("worktree %s not found", worktreePath)}
+}
+
+// worktreeKey returns the canonical form of a worktree path, or the cleaned
+// path when its directory is gone
+func worktreeKey(path string) string {
+	if resolved, err := canonicalPath(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(expandHome(path))
+}
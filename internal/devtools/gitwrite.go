@@ -0,0 +1,373 @@
+package devtools
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// discardTokenTTL is how long a discard confirmation token stays valid
+const discardTokenTTL = 2 * time.Minute
+
+// GitDiscardPlan lists what DiscardChanges will throw away. Its token must be
+// passed back to confirm the discard.
+type GitDiscardPlan struct {
+	Token     string          `json:"token"`
+	Files     []GitFileChange `json:"files"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+}
+
+// GitCommitOptions describes a commit to create
+type GitCommitOptions struct {
+	Message    string `json:"message"`
+	Amend      bool   `json:"amend"`   // replace the last commit; an empty message keeps its message
+	SignOff    bool   `json:"signOff"` // add a Signed-off-by trailer
+	AllowEmpty bool   `json:"allowEmpty"`
+}
+
+// pendingDiscard is a discard waiting for confirmation
+type pendingDiscard struct {
+	repoID      string
+	paths       []string
+	fingerprint string
+	expiresAt   time.Time
+}
+
+// StageFiles adds the current content of files, including deletions, to the index
+func (gm *GitRepoManager) StageFiles(repoID string, paths []string) error {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return &GitError{Op: "add", Code: GitErrInvalidName, Message: "no files selected"}
+	}
+
+	if _, err := runGit(path, append([]string{"add", "-A", "--"}, paths...)...); err != nil {
+		return err
+	}
+	gm.RefreshRepo(repoID)
+	return nil
+}
+
+// UnstageFiles removes files from the index, keeping their working tree changes
+func (gm *GitRepoManager) UnstageFiles(repoID string, paths []string) error {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return &GitError{Op: "restore", Code: GitErrInvalidName, Message: "no files selected"}
+	}
+
+	// Before the first commit there is no HEAD to restore from
+	if _, err := runGit(path, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		_, err = runGit(path, append([]string{"rm", "--cached", "-r", "-q", "--"}, paths...)...)
+		if err != nil {
+			return err
+		}
+	} else if _, err := runGit(path, append([]string{"restore", "--staged", "--"}, paths...)...); err != nil {
+		return err
+	}
+	gm.RefreshRepo(repoID)
+	return nil
+}
+
+// StageHunk stages one hunk of a file's unstaged changes. The hunk is identified
+// by its header as returned by GetFileDiff.
+func (gm *GitRepoManager) StageHunk(repoID, file, hunkHeader string) error {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return err
+	}
+
+	// Untracked files need an index entry before their hunks can be staged
+	untracked, err := runGit(path, "ls-files", "--others", "--exclude-standard", "--", file)
+	if err != nil {
+		return err
+	}
+	intentToAdd := strings.TrimSpace(untracked) != ""
+	if intentToAdd {
+		if _, err := runGit(path, "add", "--intent-to-add", "--", file); err != nil {
+			return err
+		}
+	}
+
+	patch, err := hunkPatch(path, file, hunkHeader, false)
+	if err == nil {
+		_, err = runGitInput(path, patch, "apply", "--cached", "-")
+	}
+	if err != nil {
+		// Leave an untracked file untracked rather than half-added
+		if intentToAdd {
+			runGit(path, "rm", "--cached", "--quiet", "--", file)
+		}
+		return err
+	}
+	gm.RefreshRepo(repoID)
+	return nil
+}
+
+// UnstageHunk removes one hunk of a file's staged changes from the index
+func (gm *GitRepoManager) UnstageHunk(repoID, file, hunkHeader string) error {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return err
+	}
+
+	patch, err := hunkPatch(path, file, hunkHeader, true)
+	if err != nil {
+		return err
+	}
+	if _, err := runGitInput(path, patch, "apply", "--cached", "--reverse", "-"); err != nil {
+		return err
+	}
+	gm.RefreshRepo(repoID)
+	return nil
+}
+
+// hunkPatch builds a patch containing the file header and a single hunk of a
+// file's staged or unstaged diff
+func hunkPatch(path, file, hunkHeader string, staged bool) (string, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--no-renames"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	output, err := runGit(path, append(args, "--", file)...)
+	if err != nil {
+		return "", err
+	}
+
+	var header, hunk []string
+	inHunk, found := false, false
+	for _, line := range strings.SplitAfter(output, "\n") {
+		if strings.HasPrefix(line, "@@") {
+			if found {
+				break
+			}
+			inHunk = true
+			found = strings.TrimRight(line, "\n") == hunkHeader
+		}
+		switch {
+		case !inHunk:
+			header = append(header, line)
+		case found:
+			hunk = append(hunk, line)
+		}
+	}
+
+	if !found {
+		return "", &GitError{Op: "apply", Code: GitErrNotFound, Message: "hunk not found; the file may have changed, refresh the diff"}
+	}
+	return strings.Join(header, "") + strings.Join(hunk, ""), nil
+}
+
+// PrepareDiscard returns the changes that discarding the working tree changes
+// of paths would lose, with a token that confirms the discard
+func (gm *GitRepoManager) PrepareDiscard(repoID string, paths []string) (GitDiscardPlan, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return GitDiscardPlan{}, err
+	}
+	if len(paths) == 0 {
+		return GitDiscardPlan{}, &GitError{Op: "restore", Code: GitErrInvalidName, Message: "no files selected"}
+	}
+
+	changes, err := gm.GetRepoChanges(repoID)
+	if err != nil {
+		return GitDiscardPlan{}, err
+	}
+	selected := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		selected[p] = true
+	}
+	plan := GitDiscardPlan{Files: []GitFileChange{}, ExpiresAt: time.Now().Add(discardTokenTTL)}
+	for _, change := range changes {
+		if selected[change.Path] && (change.Untracked || change.WorktreeStatus != ".") {
+			plan.Files = append(plan.Files, change)
+		}
+	}
+	if len(plan.Files) == 0 {
+		return GitDiscardPlan{}, &GitError{Op: "restore", Code: GitErrNotFound, Message: "the selected files have no working tree changes"}
+	}
+
+	// Only what the plan shows is discarded
+	planned := make([]string, 0, len(plan.Files))
+	for _, change := range plan.Files {
+		planned = append(planned, change.Path)
+	}
+	fingerprint, err := worktreeFingerprint(path, planned)
+	if err != nil {
+		return GitDiscardPlan{}, err
+	}
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return GitDiscardPlan{}, fmt.Errorf("error generating token: %v", err)
+	}
+	plan.Token = hex.EncodeToString(tokenBytes)
+
+	gm.mutex.Lock()
+	if gm.discards == nil {
+		gm.discards = make(map[string]pendingDiscard)
+	}
+	for token, pending := range gm.discards {
+		if time.Now().After(pending.expiresAt) {
+			delete(gm.discards, token)
+		}
+	}
+	gm.discards[plan.Token] = pendingDiscard{
+		repoID:      repoID,
+		paths:       planned,
+		fingerprint: fingerprint,
+		expiresAt:   plan.ExpiresAt,
+	}
+	gm.mutex.Unlock()
+
+	return plan, nil
+}
+
+// DiscardChanges discards the working tree changes confirmed by a token from
+// PrepareDiscard. Staged changes are kept; untracked files and directories are deleted. The
+// discard is refused if the files changed since the token was issued.
+func (gm *GitRepoManager) DiscardChanges(repoID, token string) error {
+	gm.mutex.Lock()
+	pending, exists := gm.discards[token]
+	delete(gm.discards, token)
+	gm.mutex.Unlock()
+
+	if !exists || pending.repoID != repoID || time.Now().After(pending.expiresAt) {
+		return &GitError{Op: "restore", Code: GitErrInvalidToken, Message: "the confirmation has expired, review the changes again"}
+	}
+
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return err
+	}
+	fingerprint, err := worktreeFingerprint(path, pending.paths)
+	if err != nil {
+		return err
+	}
+	if fingerprint != pending.fingerprint {
+		return &GitError{Op: "restore", Code: GitErrInvalidToken, Message: "the files changed after the discard was confirmed, review the changes again"}
+	}
+
+	// Restore tracked files from the index and delete untracked ones
+	output, err := runGit(path, append([]string{"ls-files", "-z", "--"}, pending.paths...)...)
+	if err != nil {
+		return err
+	}
+	tracked := make(map[string]bool)
+	for _, file := range strings.Split(output, "\x00") {
+		tracked[file] = true
+	}
+	var restore, clean []string
+	for _, file := range pending.paths {
+		if tracked[file] {
+			restore = append(restore, file)
+		} else {
+			clean = append(clean, file)
+		}
+	}
+
+	if len(restore) > 0 {
+		if _, err := runGit(path, append([]string{"restore", "--worktree", "--"}, restore...)...); err != nil {
+			return err
+		}
+	}
+	if len(clean) > 0 {
+		if _, err := runGit(path, append([]string{"clean", "-f", "-d", "-q", "--"}, clean...)...); err != nil {
+			return err
+		}
+	}
+
+	gm.RefreshRepo(repoID)
+	return nil
+}
+
+// worktreeFingerprint hashes the status, unstaged diff and untracked content of paths, so that a
+// confirmed discard can check nothing changed in between
+func worktreeFingerprint(path string, paths []string) (string, error) {
+	status, err := runGit(path, append([]string{"status", "--porcelain=v2", "-z", "--untracked-files=all", "--"}, paths...)...)
+	if err != nil {
+		return "", err
+	}
+	diff, err := runGit(path, append([]string{"diff", "--no-color", "--no-ext-diff", "--binary", "--"}, paths...)...)
+	if err != nil {
+		return "", err
+	}
+
+	// Untracked files are not part of the diff, so hash their content too
+	untracked, err := runGit(path, append([]string{"ls-files", "--others", "--exclude-standard", "-z", "--"}, paths...)...)
+	if err != nil {
+		return "", err
+	}
+	var objects string
+	if files := strings.Split(strings.TrimSuffix(untracked, "\x00"), "\x00"); untracked != "" {
+		if objects, err = runGit(path, append([]string{"hash-object", "--"}, files...)...); err != nil {
+			return "", err
+		}
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(status))
+	hash.Write([]byte(diff))
+	hash.Write([]byte(objects))
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Commit commits the staged changes and returns the new commit
+func (gm *GitRepoManager) Commit(repoID string, options GitCommitOptions) (GitCommit, error) {
+	path, err := gm.repoPath(repoID)
+	if err != nil {
+		return GitCommit{}, err
+	}
+
+	message := strings.TrimSpace(options.Message)
+	if message == "" && !options.Amend {
+		return GitCommit{}, &GitError{Op: "commit", Code: GitErrInvalidName, Message: "commit message is empty"}
+	}
+
+	// git diff --cached --quiet exits with 1 when something is staged
+	if !options.Amend && !options.AllowEmpty {
+		if err := exec.Command("git", "-C", path, "diff", "--cached", "--quiet").Run(); err == nil {
+			return GitCommit{}, &GitError{Op: "commit", Code: GitErrNothingToCommit, Message: "no changes are staged"}
+		}
+	}
+
+	args := []string{"commit", "--quiet"}
+	if options.Amend {
+		args = append(args, "--amend")
+	}
+	if options.SignOff {
+		args = append(args, "--signoff")
+	}
+	if options.AllowEmpty {
+		args = append(args, "--allow-empty")
+	}
+	if message == "" {
+		args = append(args, "--no-edit")
+		_, err = runGit(path, args...)
+	} else {
+		_, err = runGitInput(path, message+"\n", append(args, "--file=-")...)
+	}
+	if err != nil {
+		return GitCommit{}, err
+	}
+
+	output, err := runGit(path, "log", "-1", gitCommitFormat)
+	if err != nil {
+		return GitCommit{}, err
+	}
+	commits := parseCommits(output)
+	if len(commits) == 0 {
+		return GitCommit{}, &GitError{Op: "commit", Code: GitErrCommand, Message: "commit was created but could not be read"}
+	}
+
+	gm.RefreshRepo(repoID)
+	return commits[0], nil
+}
@@ -0,0 +1,352 @@
+package devtools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hunkHeaders returns the hunk headers of a file's unstaged, or staged, diff
+func hunkHeaders(t *testing.T, dir, file string, staged bool) []string {
+	t.Helper()
+
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--no-renames"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	var headers []string
+	for _, line := range strings.Split(gitTest(t, dir, append(args, "--", file)...), "\n") {
+		if strings.HasPrefix(line, "@@") {
+			headers = append(headers, line)
+		}
+	}
+	return headers
+}
+
+// readTestFile returns the content of a file in a repository
+func readTestFile(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return string(content)
+}
+
+// fileExists reports whether a file exists in a repository
+func fileExists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+func TestDiscardChanges(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	commitTestFile(t, dir, "app.go", "package app\n", "Add app")
+
+	// A staged change, an unstaged change on top of it and two untracked files
+	writeTestFile(t, dir, "app.go", "package app\n\n// staged\n")
+	gitTest(t, dir, "add", "app.go")
+	writeTestFile(t, dir, "app.go", "package app\n\n// staged\n// unstaged\n")
+	writeTestFile(t, dir, "scratch/notes.txt", "delete me\n")
+	writeTestFile(t, dir, "keep.txt", "not selected\n")
+	repoID := addTestRepo(t, gm, dir)
+
+	// Untracked directories are listed, and selected, as a whole
+	plan, err := gm.PrepareDiscard(repoID, []string{"app.go", "scratch/"})
+	if err != nil {
+		t.Fatalf("PrepareDiscard: %v", err)
+	}
+	if len(plan.Files) != 2 || plan.Token == "" || !plan.ExpiresAt.After(time.Now()) {
+		t.Fatalf("plan = %+v, want both files and a valid token", plan)
+	}
+
+	if err := gm.DiscardChanges(repoID, plan.Token); err != nil {
+		t.Fatalf("DiscardChanges: %v", err)
+	}
+	if content := readTestFile(t, dir, "app.go"); content != "package app\n\n// staged\n" {
+		t.Errorf("app.go = %q, want the staged content", content)
+	}
+	if staged := gitTest(t, dir, "diff", "--cached", "--name-only"); staged != "app.go" {
+		t.Errorf("staged files = %q, want app.go still staged", staged)
+	}
+	if fileExists(dir, "scratch") {
+		t.Errorf("untracked scratch/ was not deleted")
+	}
+	if !fileExists(dir, "keep.txt") {
+		t.Errorf("keep.txt was deleted although it wasn't selected")
+	}
+
+	// Tokens can only be used once
+	wantGitError(t, gm.DiscardChanges(repoID, plan.Token), GitErrInvalidToken)
+}
+
+func TestDiscardChangesRefusesExpiredToken(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	writeTestFile(t, dir, "README.md", "changed\n")
+	writeTestFile(t, dir, "new.txt", "new\n")
+	repoID := addTestRepo(t, gm, dir)
+	otherID := addTestRepo(t, gm, initTestRepo(t))
+
+	wantGitError(t, gm.DiscardChanges(repoID, "unknown"), GitErrInvalidToken)
+
+	plan, err := gm.PrepareDiscard(repoID, []string{"README.md", "new.txt"})
+	if err != nil {
+		t.Fatalf("PrepareDiscard: %v", err)
+	}
+	wantGitError(t, gm.DiscardChanges(otherID, plan.Token), GitErrInvalidToken)
+
+	plan, err = gm.PrepareDiscard(repoID, []string{"README.md", "new.txt"})
+	if err != nil {
+		t.Fatalf("PrepareDiscard: %v", err)
+	}
+	gm.mutex.Lock()
+	pending := gm.discards[plan.Token]
+	pending.expiresAt = time.Now().Add(-time.Second)
+	gm.discards[plan.Token] = pending
+	gm.mutex.Unlock()
+
+	wantGitError(t, gm.DiscardChanges(repoID, plan.Token), GitErrInvalidToken)
+	if content := readTestFile(t, dir, "README.md"); content != "changed\n" {
+		t.Errorf("README.md = %q, want the change kept", content)
+	}
+	if !fileExists(dir, "new.txt") {
+		t.Errorf("new.txt was deleted with an expired token")
+	}
+}
+
+func TestDiscardChangesRefusesChangedFiles(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	writeTestFile(t, dir, "README.md", "changed\n")
+	writeTestFile(t, dir, "new.txt", "new\n")
+	repoID := addTestRepo(t, gm, dir)
+
+	// A tracked file edited after the plan was shown
+	plan, err := gm.PrepareDiscard(repoID, []string{"README.md"})
+	if err != nil {
+		t.Fatalf("PrepareDiscard: %v", err)
+	}
+	writeTestFile(t, dir, "README.md", "changed again\n")
+	wantGitError(t, gm.DiscardChanges(repoID, plan.Token), GitErrInvalidToken)
+	if content := readTestFile(t, dir, "README.md"); content != "changed again\n" {
+		t.Errorf("README.md = %q, want the new change kept", content)
+	}
+
+	// An untracked file edited after the plan was shown
+	plan, err = gm.PrepareDiscard(repoID, []string{"new.txt"})
+	if err != nil {
+		t.Fatalf("PrepareDiscard: %v", err)
+	}
+	writeTestFile(t, dir, "new.txt", "more work\n")
+	wantGitError(t, gm.DiscardChanges(repoID, plan.Token), GitErrInvalidToken)
+	if content := readTestFile(t, dir, "new.txt"); content != "more work\n" {
+		t.Errorf("new.txt = %q, want it kept", content)
+	}
+}
+
+func TestPrepareDiscardWithoutChanges(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	repoID := addTestRepo(t, gm, dir)
+
+	_, err := gm.PrepareDiscard(repoID, []string{"README.md"})
+	wantGitError(t, err, GitErrNotFound)
+	_, err = gm.PrepareDiscard(repoID, nil)
+	wantGitError(t, err, GitErrInvalidName)
+}
+
+func TestStageHunkOfNewFile(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	writeTestFile(t, dir, "new.txt", "one\ntwo\n")
+	repoID := addTestRepo(t, gm, dir)
+
+	if err := gm.StageHunk(repoID, "new.txt", "@@ -0,0 +1,2 @@"); err != nil {
+		t.Fatalf("StageHunk: %v", err)
+	}
+	if staged := gitTest(t, dir, "diff", "--cached", "--name-status"); staged != "A\tnew.txt" {
+		t.Errorf("staged = %q, want new.txt added", staged)
+	}
+	if content := gitTest(t, dir, "show", ":new.txt"); content != "one\ntwo" {
+		t.Errorf("staged content = %q", content)
+	}
+}
+
+func TestStageHunkUndoesIntentToAdd(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	writeTestFile(t, dir, "new.txt", "one\n")
+	repoID := addTestRepo(t, gm, dir)
+
+	wantGitError(t, gm.StageHunk(repoID, "new.txt", "@@ -0,0 +1,5 @@"), GitErrNotFound)
+	if tracked := gitTest(t, dir, "ls-files", "--", "new.txt"); tracked != "" {
+		t.Errorf("new.txt is in the index after a failed StageHunk")
+	}
+	if status := gitTest(t, dir, "status", "--porcelain", "--", "new.txt"); status != "?? new.txt" {
+		t.Errorf("status = %q, want new.txt untracked", status)
+	}
+}
+
+func TestStageAndUnstageHunk(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, strings.Repeat("x", i))
+	}
+	commitTestFile(t, dir, "lines.txt", strings.Join(lines, "\n")+"\n", "Add lines")
+
+	// Two changes far enough apart to be separate hunks
+	lines[1], lines[18] = "first change", "second change"
+	writeTestFile(t, dir, "lines.txt", strings.Join(lines, "\n")+"\n")
+	repoID := addTestRepo(t, gm, dir)
+
+	headers := hunkHeaders(t, dir, "lines.txt", false)
+	if len(headers) != 2 {
+		t.Fatalf("hunks = %v, want 2", headers)
+	}
+	if err := gm.StageHunk(repoID, "lines.txt", headers[0]); err != nil {
+		t.Fatalf("StageHunk: %v", err)
+	}
+	staged := gitTest(t, dir, "diff", "--cached")
+	if !strings.Contains(staged, "+first change") || strings.Contains(staged, "+second change") {
+		t.Errorf("staged diff = %q, want only the first change", staged)
+	}
+	if unstaged := hunkHeaders(t, dir, "lines.txt", false); len(unstaged) != 1 {
+		t.Errorf("unstaged hunks = %v, want the second change only", unstaged)
+	}
+
+	stagedHeaders := hunkHeaders(t, dir, "lines.txt", true)
+	if len(stagedHeaders) != 1 {
+		t.Fatalf("staged hunks = %v, want 1", stagedHeaders)
+	}
+	if err := gm.UnstageHunk(repoID, "lines.txt", stagedHeaders[0]); err != nil {
+		t.Fatalf("UnstageHunk: %v", err)
+	}
+	if staged := gitTest(t, dir, "diff", "--cached"); staged != "" {
+		t.Errorf("staged diff = %q, want nothing staged", staged)
+	}
+	if content := readTestFile(t, dir, "lines.txt"); !strings.Contains(content, "first change") || !strings.Contains(content, "second change") {
+		t.Errorf("working tree lost changes: %q", content)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	repoID := addTestRepo(t, gm, dir)
+
+	_, err := gm.Commit(repoID, GitCommitOptions{Message: "Nothing"})
+	wantGitError(t, err, GitErrNothingToCommit)
+
+	writeTestFile(t, dir, "README.md", "changed\n")
+	if err := gm.StageFiles(repoID, []string{"README.md"}); err != nil {
+		t.Fatalf("StageFiles: %v", err)
+	}
+	_, err = gm.Commit(repoID, GitCommitOptions{Message: "  "})
+	wantGitError(t, err, GitErrInvalidName)
+
+	commit, err := gm.Commit(repoID, GitCommitOptions{Message: "Update readme\n\nWith a body", SignOff: true})
+	if err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if commit.Subject != "Update readme" || len(commit.Parents) != 1 {
+		t.Errorf("commit = %+v, want Update readme on top of the initial commit", commit)
+	}
+	message := gitTest(t, dir, "log", "-1", "--format=%B")
+	if !strings.Contains(message, "With a body") || !strings.Contains(message, "Signed-off-by: Test <test@example.com>") {
+		t.Errorf("message = %q, want the body and a sign-off", message)
+	}
+	if repo := gm.repos[repoID]; repo.LastCommit != "Update readme" {
+		t.Errorf("repository last commit = %q, want it refreshed", repo.LastCommit)
+	}
+}
+
+func TestCommitAmend(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	commitTestFile(t, dir, "app.go", "package app\n", "Add app")
+	repoID := addTestRepo(t, gm, dir)
+	before := gitTest(t, dir, "rev-parse", "HEAD")
+
+	// Amending with an empty message keeps the message and takes staged changes
+	writeTestFile(t, dir, "app.go", "package app\n\nfunc Run() {}\n")
+	gitTest(t, dir, "add", "app.go")
+	commit, err := gm.Commit(repoID, GitCommitOptions{Amend: true})
+	if err != nil {
+		t.Fatalf("Commit amend: %v", err)
+	}
+	if commit.Subject != "Add app" || commit.Hash == before {
+		t.Errorf("commit = %+v, want Add app rewritten", commit)
+	}
+	if count := gitTest(t, dir, "rev-list", "--count", "HEAD"); count != "2" {
+		t.Errorf("commit count = %s, want 2", count)
+	}
+	if content := gitTest(t, dir, "show", "HEAD:app.go"); !strings.Contains(content, "func Run") {
+		t.Errorf("amended commit doesn't contain the staged change")
+	}
+
+	// Amending with a message and sign-off rewrites the message
+	commit, err = gm.Commit(repoID, GitCommitOptions{Message: "Add app runner", Amend: true, SignOff: true})
+	if err != nil {
+		t.Fatalf("Commit amend with message: %v", err)
+	}
+	if commit.Subject != "Add app runner" {
+		t.Errorf("subject = %q, want Add app runner", commit.Subject)
+	}
+	if message := gitTest(t, dir, "log", "-1", "--format=%B"); !strings.Contains(message, "Signed-off-by: Test <test@example.com>") {
+		t.Errorf("message = %q, want a sign-off", message)
+	}
+	if count := gitTest(t, dir, "rev-list", "--count", "HEAD"); count != "2" {
+		t.Errorf("commit count = %s, want 2", count)
+	}
+}
+
+func TestWriteOperationsTakePathsLiterally(t *testing.T) {
+	gm := newTestRepoManager(t)
+	dir := initTestRepo(t)
+	commitTestFile(t, dir, "star*.txt", "selected\n", "Add a file with a glob in its name")
+	commitTestFile(t, dir, "starX.txt", "other\n", "Add a file the glob matches")
+	writeTestFile(t, dir, "star*.txt", "selected, changed\n")
+	writeTestFile(t, dir, "starX.txt", "other, changed\n")
+	writeTestFile(t, dir, "starY.txt", "untracked\n")
+	repoID := addTestRepo(t, gm, dir)
+
+	if err := gm.StageFiles(repoID, []string{"star*.txt"}); err != nil {
+		t.Fatalf("StageFiles: %v", err)
+	}
+	if staged := gitTest(t, dir, "diff", "--cached", "--name-only"); staged != "star*.txt" {
+		t.Errorf("staged files = %q, want only star*.txt", staged)
+	}
+	if err := gm.UnstageFiles(repoID, []string{"star*.txt"}); err != nil {
+		t.Fatalf("UnstageFiles: %v", err)
+	}
+	if staged := gitTest(t, dir, "diff", "--cached", "--name-only"); staged != "" {
+		t.Errorf("staged files = %q, want none", staged)
+	}
+
+	plan, err := gm.PrepareDiscard(repoID, []string{"star*.txt"})
+	if err != nil {
+		t.Fatalf("PrepareDiscard: %v", err)
+	}
+	if len(plan.Files) != 1 || plan.Files[0].Path != "star*.txt" {
+		t.Fatalf("plan = %+v, want star*.txt only", plan.Files)
+	}
+	if err := gm.DiscardChanges(repoID, plan.Token); err != nil {
+		t.Fatalf("DiscardChanges: %v", err)
+	}
+	if content := readTestFile(t, dir, "star*.txt"); content != "selected\n" {
+		t.Errorf("star*.txt = %q, want it restored", content)
+	}
+	if content := readTestFile(t, dir, "starX.txt"); content != "other, changed\n" {
+		t.Errorf("starX.txt = %q, want it left alone", content)
+	}
+	if !fileExists(dir, "starY.txt") {
+		t.Errorf("untracked starY.txt was deleted although it wasn't selected")
+	}
+}
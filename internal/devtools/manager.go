@@ -6,12 +6,17 @@ import (
 	"path/filepath"
 )
 
+// EventEmitter pushes a named event with optional data to the frontend
+type EventEmitter func(eventName string, data ...interface{})
+
 // DevToolsManager is the main manager for developer tools
 type DevToolsManager struct {
 	serverManager   *ServerManager
 	databaseManager *DatabaseManager
 	apiTester       *APITester
 	gitRepoManager  *GitRepoManager
+	mockServer      *MockServer
+	recordingProxy  *RecordingProxy
 	initialized     bool
 }
 
@@ -22,6 +27,8 @@ func NewDevToolsManager() *DevToolsManager {
 		databaseManager: GetDatabaseManager(),
 		apiTester:       NewAPITester(),
 		gitRepoManager:  GetGitRepoManager(),
+		mockServer:      GetMockServer(),
+		recordingProxy:  GetRecordingProxy(),
 	}
 }
 
@@ -48,6 +55,28 @@ func (dtm *DevToolsManager) Initialize() error {
 	return nil
 }
 
+// SetEventEmitter sets the function used by the tools to push events to the frontend
+func (dtm *DevToolsManager) SetEventEmitter(emit EventEmitter) {
+	dtm.apiTester.SetEventEmitter(emit)
+	dtm.mockServer.SetEventEmitter(emit)
+	dtm.recordingProxy.SetEventEmitter(emit)
+	dtm.gitRepoManager.SetEventEmitter(emit)
+}
+
+// Shutdown stops all background activity of the tools
+func (dtm *DevToolsManager) Shutdown() {
+	dtm.apiTester.CloseAllStreams()
+	dtm.apiTester.CancelAllBenchmarks()
+	if dtm.mockServer.GetInfo().Running {
+		dtm.mockServer.Stop()
+	}
+	if dtm.recordingProxy.GetInfo().Running {
+		dtm.recordingProxy.Stop()
+	}
+	dtm.gitRepoManager.StopAutoFetch()
+	dtm.gitRepoManager.StopWatching()
+}
+
 // GetAllServers returns all registered servers
 func (dtm *DevToolsManager) GetAllServers() []ServerInfo {
 	return dtm.serverManager.GetAllServers()
@@ -113,6 +142,251 @@ func (dtm *DevToolsManager) GetSavedAPIRequests() []APIRequest {
 	return dtm.apiTester.GetSavedRequests()
 }
 
+// SetAPIAuthSecrets stores the secrets referenced by an API request's auth block
+func (dtm *DevToolsManager) SetAPIAuthSecrets(ref string, secrets AuthSecrets) error {
+	return dtm.apiTester.secrets.Set(ref, secrets)
+}
+
+// DeleteAPIAuthSecrets removes stored API auth secrets
+func (dtm *DevToolsManager) DeleteAPIAuthSecrets(ref string) error {
+	return dtm.apiTester.secrets.Delete(ref)
+}
+
+// ClearAPITokenCache discards cached OAuth2 access tokens
+func (dtm *DevToolsManager) ClearAPITokenCache() {
+	dtm.apiTester.ClearTokenCache()
+}
+
+// GetAPICookies returns the cookies an environment's jar would send to a URL
+func (dtm *DevToolsManager) GetAPICookies(environment, url string) ([]APICookie, error) {
+	return dtm.apiTester.GetCookies(environment, url)
+}
+
+// ClearAPICookies discards the cookie jar of an environment
+func (dtm *DevToolsManager) ClearAPICookies(environment string) {
+	dtm.apiTester.ClearCookies(environment)
+}
+
+// SendGraphQLRequest validates and sends a GraphQL operation
+func (dtm *DevToolsManager) SendGraphQLRequest(req GraphQLRequest) APIResponse {
+	return dtm.apiTester.SendGraphQL(req)
+}
+
+// IntrospectGraphQLSchema fetches and caches the schema of a GraphQL endpoint
+func (dtm *DevToolsManager) IntrospectGraphQLSchema(req GraphQLRequest, refresh bool) (GraphQLSchemaInfo, error) {
+	return dtm.apiTester.IntrospectGraphQL(req, refresh)
+}
+
+// ValidateGraphQLQuery checks a GraphQL operation against the endpoint's schema
+func (dtm *DevToolsManager) ValidateGraphQLQuery(req GraphQLRequest) ([]string, error) {
+	return dtm.apiTester.ValidateGraphQL(req)
+}
+
+// GetGraphQLOperations returns the queries, mutations and subscriptions of a cached schema
+func (dtm *DevToolsManager) GetGraphQLOperations(url string) ([]GraphQLOperation, error) {
+	return dtm.apiTester.GetGraphQLOperations(url)
+}
+
+// OpenAPIStream opens a WebSocket or Server-Sent Events session
+func (dtm *DevToolsManager) OpenAPIStream(config StreamConfig) (StreamSession, error) {
+	return dtm.apiTester.OpenStream(config)
+}
+
+// SendAPIStreamMessage sends a text or base64-encoded binary frame on a WebSocket session
+func (dtm *DevToolsManager) SendAPIStreamMessage(sessionID, data string, binary bool) error {
+	return dtm.apiTester.SendStreamMessage(sessionID, data, binary)
+}
+
+// CloseAPIStream closes a streaming session with a close code and reason
+func (dtm *DevToolsManager) CloseAPIStream(sessionID string, code int, reason string) (StreamSession, error) {
+	return dtm.apiTester.CloseStream(sessionID, code, reason)
+}
+
+// RemoveAPIStream closes a streaming session and discards its transcript
+func (dtm *DevToolsManager) RemoveAPIStream(sessionID string) error {
+	return dtm.apiTester.RemoveStream(sessionID)
+}
+
+// GetAPIStreams returns all streaming sessions
+func (dtm *DevToolsManager) GetAPIStreams() []StreamSession {
+	return dtm.apiTester.GetStreams()
+}
+
+// GetAPIStreamTranscript returns the messages of a streaming session
+func (dtm *DevToolsManager) GetAPIStreamTranscript(sessionID string) ([]StreamMessage, error) {
+	return dtm.apiTester.GetStreamTranscript(sessionID)
+}
+
+// SaveAPIStreamTranscript writes a streaming session transcript to a JSON file
+func (dtm *DevToolsManager) SaveAPIStreamTranscript(sessionID, path string) error {
+	return dtm.apiTester.SaveStreamTranscript(sessionID, path)
+}
+
+// resolveGRPCTarget points a gRPC target at the .proto files of its registered Git repository
+func (dtm *DevToolsManager) resolveGRPCTarget(target GRPCTarget) (GRPCTarget, error) {
+	if target.ProtoRepoID == "" {
+		return target, nil
+	}
+
+	path, err := dtm.gitRepoManager.repoPath(target.ProtoRepoID)
+	if err != nil {
+		return target, err
+	}
+	target.ProtoRoot = protoRootForRepo(path, target.ProtoRoot)
+	return target, nil
+}
+
+// ListGRPCServices lists the services and methods of a gRPC target
+func (dtm *DevToolsManager) ListGRPCServices(target GRPCTarget) ([]GRPCService, error) {
+	target, err := dtm.resolveGRPCTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	return dtm.apiTester.ListGRPCServices(target)
+}
+
+// InvokeGRPC calls a unary or server-streaming gRPC method
+func (dtm *DevToolsManager) InvokeGRPC(req GRPCRequest) GRPCResponse {
+	target, err := dtm.resolveGRPCTarget(req.Target)
+	if err != nil {
+		return GRPCResponse{StatusName: "Error", Error: err.Error()}
+	}
+	req.Target = target
+	return dtm.apiTester.InvokeGRPC(req)
+}
+
+// StartMockServer starts the mock server on a local port
+func (dtm *DevToolsManager) StartMockServer(port int) (MockServerInfo, error) {
+	return dtm.mockServer.Start(port)
+}
+
+// StopMockServer stops the mock server
+func (dtm *DevToolsManager) StopMockServer() (MockServerInfo, error) {
+	return dtm.mockServer.Stop()
+}
+
+// GetMockServerInfo returns the state of the mock server
+func (dtm *DevToolsManager) GetMockServerInfo() MockServerInfo {
+	return dtm.mockServer.GetInfo()
+}
+
+// GetMockRoutes returns all mock routes
+func (dtm *DevToolsManager) GetMockRoutes() []MockRoute {
+	return dtm.mockServer.GetRoutes()
+}
+
+// SaveMockRoute adds a mock route or replaces the one with the same ID
+func (dtm *DevToolsManager) SaveMockRoute(route MockRoute) (MockRoute, error) {
+	return dtm.mockServer.AddRoute(route)
+}
+
+// RemoveMockRoute removes a mock route
+func (dtm *DevToolsManager) RemoveMockRoute(routeID string) error {
+	return dtm.mockServer.RemoveRoute(routeID)
+}
+
+// CreateMockRouteFromResponse creates a mock route that replays an API response
+func (dtm *DevToolsManager) CreateMockRouteFromResponse(req APIRequest, resp APIResponse) (MockRoute, error) {
+	return dtm.mockServer.AddRouteFromResponse(req, resp)
+}
+
+// GetMockRequestLog returns the requests received by the mock server
+func (dtm *DevToolsManager) GetMockRequestLog() []MockRequestLog {
+	return dtm.mockServer.GetRequestLog()
+}
+
+// ClearMockRequestLog discards the mock server request log
+func (dtm *DevToolsManager) ClearMockRequestLog() {
+	dtm.mockServer.ClearRequestLog()
+}
+
+// StartAPIBenchmark starts a load test of an API request
+func (dtm *DevToolsManager) StartAPIBenchmark(config BenchmarkConfig) (BenchmarkResult, error) {
+	return dtm.apiTester.StartBenchmark(config)
+}
+
+// CancelAPIBenchmark stops a running load test
+func (dtm *DevToolsManager) CancelAPIBenchmark(benchmarkID string) (BenchmarkResult, error) {
+	return dtm.apiTester.CancelBenchmark(benchmarkID)
+}
+
+// GetAPIBenchmark returns the progress or result of a load test
+func (dtm *DevToolsManager) GetAPIBenchmark(benchmarkID string) (BenchmarkResult, error) {
+	return dtm.apiTester.GetBenchmark(benchmarkID)
+}
+
+// StartRecordingProxy starts the recording proxy
+func (dtm *DevToolsManager) StartRecordingProxy(config ProxyConfig) (ProxyInfo, error) {
+	return dtm.recordingProxy.Start(config)
+}
+
+// StopRecordingProxy stops the recording proxy
+func (dtm *DevToolsManager) StopRecordingProxy() (ProxyInfo, error) {
+	return dtm.recordingProxy.Stop()
+}
+
+// GetRecordingProxyInfo returns the state of the recording proxy
+func (dtm *DevToolsManager) GetRecordingProxyInfo() ProxyInfo {
+	return dtm.recordingProxy.GetInfo()
+}
+
+// GetProxyRecords returns the exchanges captured by the recording proxy
+func (dtm *DevToolsManager) GetProxyRecords() []ProxyRecord {
+	return dtm.recordingProxy.GetRecords()
+}
+
+// DeleteProxyRecord removes a captured exchange
+func (dtm *DevToolsManager) DeleteProxyRecord(recordID string) error {
+	return dtm.recordingProxy.DeleteRecord(recordID)
+}
+
+// ClearProxyRecords discards all captured exchanges
+func (dtm *DevToolsManager) ClearProxyRecords() {
+	dtm.recordingProxy.ClearRecords()
+}
+
+// ReplayProxyRecord sends a captured request again through the API tester,
+// including the credential headers withheld from the record
+func (dtm *DevToolsManager) ReplayProxyRecord(recordID string) (APIResponse, error) {
+	record, err := dtm.recordingProxy.GetRecord(recordID)
+	if err != nil {
+		return APIResponse{}, err
+	}
+	if record.Tunnel {
+		return APIResponse{}, fmt.Errorf("tunnelled requests can't be replayed")
+	}
+	if record.RequestTruncated {
+		return APIResponse{}, fmt.Errorf("request body was truncated, it can't be replayed")
+	}
+	return dtm.apiTester.SendRequest(record.replayRequest()), nil
+}
+
+// SaveProxyRecordCredentials stores the credentials captured with an exchange
+// in the secret store and returns its request for saving
+func (dtm *DevToolsManager) SaveProxyRecordCredentials(recordID string) (APIRequest, error) {
+	return dtm.recordingProxy.SaveRecordCredentials(recordID)
+}
+
+// CreateMockRouteFromRecord creates a mock route that replays a captured response
+func (dtm *DevToolsManager) CreateMockRouteFromRecord(recordID string) (MockRoute, error) {
+	record, err := dtm.recordingProxy.GetRecord(recordID)
+	if err != nil {
+		return MockRoute{}, err
+	}
+	if record.Tunnel {
+		return MockRoute{}, fmt.Errorf("tunnelled requests can't be turned into mock routes")
+	}
+	if record.ResponseTruncated {
+		return MockRoute{}, fmt.Errorf("response body was truncated, it can't be replayed")
+	}
+	return dtm.mockServer.AddRouteFromResponse(record.Request, record.Response)
+}
+
+// GenerateRequestCode renders an API request as a code snippet
+func (dtm *DevToolsManager) GenerateRequestCode(req APIRequest, language CodeLanguage) (string, error) {
+	return dtm.apiTester.GenerateCode(req, language)
+}
+
 // GetAllGitRepos returns all registered Git repositories
 func (dtm *DevToolsManager) GetAllGitRepos() []GitRepoInfo {
 	return dtm.gitRepoManager.GetAllRepos()
@@ -139,10 +413,256 @@ func (dtm *DevToolsManager) RemoveGitRepo(repoID string) error {
 }
 
 // GetGitRepoChanges returns the changes in a Git repository
-func (dtm *DevToolsManager) GetGitRepoChanges(repoID string) ([]string, error) {
+func (dtm *DevToolsManager) GetGitRepoChanges(repoID string) ([]GitFileChange, error) {
 	return dtm.gitRepoManager.GetRepoChanges(repoID)
 }
 
+// GetGitCommitLog returns a page of the commit history of a Git repository
+func (dtm *DevToolsManager) GetGitCommitLog(repoID string, options GitLogOptions) (GitLogPage, error) {
+	return dtm.gitRepoManager.GetCommitLog(repoID, options)
+}
+
+// GetGitCommitDetail returns a commit with the files it changed
+func (dtm *DevToolsManager) GetGitCommitDetail(repoID, hash string) (GitCommitDetail, error) {
+	return dtm.gitRepoManager.GetCommitDetail(repoID, hash)
+}
+
+// ListGitBranches returns the local and remote branches of a Git repository
+func (dtm *DevToolsManager) ListGitBranches(repoID string) ([]GitBranch, error) {
+	return dtm.gitRepoManager.ListBranches(repoID)
+}
+
+// CreateGitBranch creates a branch from a ref and optionally switches to it
+func (dtm *DevToolsManager) CreateGitBranch(repoID, name, startPoint string, checkout bool) (GitBranch, error) {
+	return dtm.gitRepoManager.CreateBranch(repoID, name, startPoint, checkout)
+}
+
+// CheckoutGitBranch switches branches, refusing or stashing when the tree is dirty
+func (dtm *DevToolsManager) CheckoutGitBranch(repoID, name, dirtyPolicy string) (GitCheckoutResult, error) {
+	return dtm.gitRepoManager.CheckoutBranch(repoID, name, dirtyPolicy)
+}
+
+// DeleteGitBranch deletes a local branch
+func (dtm *DevToolsManager) DeleteGitBranch(repoID, name string, force bool) error {
+	return dtm.gitRepoManager.DeleteBranch(repoID, name, force)
+}
+
+// SetGitBranchUpstream sets or unsets the upstream of a local branch
+func (dtm *DevToolsManager) SetGitBranchUpstream(repoID, name, upstream string) (GitBranch, error) {
+	return dtm.gitRepoManager.SetUpstream(repoID, name, upstream)
+}
+
+// GetGitFileDiff returns the staged or unstaged diff of a file in a Git repository
+func (dtm *DevToolsManager) GetGitFileDiff(repoID, path string, staged bool) (GitFileDiff, error) {
+	return dtm.gitRepoManager.GetFileDiff(repoID, path, staged)
+}
+
+// StageGitFiles stages files in a Git repository
+func (dtm *DevToolsManager) StageGitFiles(repoID string, paths []string) error {
+	return dtm.gitRepoManager.StageFiles(repoID, paths)
+}
+
+// UnstageGitFiles unstages files in a Git repository
+func (dtm *DevToolsManager) UnstageGitFiles(repoID string, paths []string) error {
+	return dtm.gitRepoManager.UnstageFiles(repoID, paths)
+}
+
+// StageGitHunk stages one hunk of a file's unstaged changes
+func (dtm *DevToolsManager) StageGitHunk(repoID, path, hunkHeader string) error {
+	return dtm.gitRepoManager.StageHunk(repoID, path, hunkHeader)
+}
+
+// UnstageGitHunk unstages one hunk of a file's staged changes
+func (dtm *DevToolsManager) UnstageGitHunk(repoID, path, hunkHeader string) error {
+	return dtm.gitRepoManager.UnstageHunk(repoID, path, hunkHeader)
+}
+
+// PrepareGitDiscard lists the changes a discard would lose and returns a confirmation token
+func (dtm *DevToolsManager) PrepareGitDiscard(repoID string, paths []string) (GitDiscardPlan, error) {
+	return dtm.gitRepoManager.PrepareDiscard(repoID, paths)
+}
+
+// DiscardGitChanges discards the working tree changes confirmed by a token
+func (dtm *DevToolsManager) DiscardGitChanges(repoID, token string) error {
+	return dtm.gitRepoManager.DiscardChanges(repoID, token)
+}
+
+// CommitGitChanges commits the staged changes of a Git repository
+func (dtm *DevToolsManager) CommitGitChanges(repoID string, options GitCommitOptions) (GitCommit, error) {
+	return dtm.gitRepoManager.Commit(repoID, options)
+}
+
+// FetchGitRepo fetches all remotes of a Git repository and returns the branches with new upstream commits
+func (dtm *DevToolsManager) FetchGitRepo(repoID string) ([]GitRemoteChange, error) {
+	return dtm.gitRepoManager.FetchRepo(repoID)
+}
+
+// SetGitRepoAutoFetch enables or disables background fetching for a Git repository
+func (dtm *DevToolsManager) SetGitRepoAutoFetch(repoID string, enabled bool) (GitRepoInfo, error) {
+	return dtm.gitRepoManager.SetAutoFetch(repoID, enabled)
+}
+
+// StartGitAutoFetch starts fetching all Git repositories in the background
+func (dtm *DevToolsManager) StartGitAutoFetch(config GitFetchConfig) GitFetchStatus {
+	return dtm.gitRepoManager.StartAutoFetch(config)
+}
+
+// ResumeGitAutoFetch starts fetching Git repositories in the background with
+// the settings it last ran with, unless it was stopped
+func (dtm *DevToolsManager) ResumeGitAutoFetch() GitFetchStatus {
+	return dtm.gitRepoManager.ResumeAutoFetch()
+}
+
+// StopGitAutoFetch stops fetching Git repositories in the background until it is started again
+func (dtm *DevToolsManager) StopGitAutoFetch() {
+	dtm.gitRepoManager.DisableAutoFetch()
+}
+
+// GetGitAutoFetchStatus returns the state of the background fetch scheduler
+func (dtm *DevToolsManager) GetGitAutoFetchStatus() GitFetchStatus {
+	return dtm.gitRepoManager.GetAutoFetchStatus()
+}
+
+// StartGitWatching refreshes Git repositories when their files change
+func (dtm *DevToolsManager) StartGitWatching() error {
+	return dtm.gitRepoManager.StartWatching()
+}
+
+// StopGitWatching stops watching Git repositories for changes
+func (dtm *DevToolsManager) StopGitWatching() {
+	dtm.gitRepoManager.StopWatching()
+}
+
+// ScanForGitRepos finds Git repositories, worktrees and submodules below a directory
+func (dtm *DevToolsManager) ScanForGitRepos(root string, maxDepth int) ([]GitRepoCandidate, error) {
+	return dtm.gitRepoManager.ScanForRepos(root, maxDepth)
+}
+
+// AddScannedGitRepos registers repositories found by ScanForGitRepos, skipping duplicates
+func (dtm *DevToolsManager) AddScannedGitRepos(candidates []GitRepoCandidate) []GitRepoImportResult {
+	return dtm.gitRepoManager.AddScannedRepos(candidates)
+}
+
+// BatchFetchGitRepos fetches all remotes of several Git repositories
+func (dtm *DevToolsManager) BatchFetchGitRepos(repoIDs []string) []GitBatchResult {
+	return dtm.gitRepoManager.BatchFetch(repoIDs)
+}
+
+// BatchPullGitRepos fast-forwards several Git repositories to their upstream
+func (dtm *DevToolsManager) BatchPullGitRepos(repoIDs []string) []GitBatchResult {
+	return dtm.gitRepoManager.BatchPull(repoIDs)
+}
+
+// BatchCheckoutGitBranch switches several Git repositories to a branch where it exists
+func (dtm *DevToolsManager) BatchCheckoutGitBranch(repoIDs []string, branch string) []GitBatchResult {
+	return dtm.gitRepoManager.BatchCheckout(repoIDs, branch)
+}
+
+// BatchRunGitRepoCommand runs a shell command in several Git repositories
+func (dtm *DevToolsManager) BatchRunGitRepoCommand(repoIDs []string, command string) []GitBatchResult {
+	return dtm.gitRepoManager.BatchRunCommand(repoIDs, command)
+}
+
+// SetGitRepoTags replaces the tags of a Git repository
+func (dtm *DevToolsManager) SetGitRepoTags(repoID string, tags []string) (GitRepoInfo, error) {
+	return dtm.gitRepoManager.SetRepoTags(repoID, tags)
+}
+
+// GetGitRepoTags returns every tag used by Git repositories
+func (dtm *DevToolsManager) GetGitRepoTags() []string {
+	return dtm.gitRepoManager.GetRepoTags()
+}
+
+// GetGitRepoGroups returns all Git repository groups
+func (dtm *DevToolsManager) GetGitRepoGroups() []GitRepoGroup {
+	return dtm.gitRepoManager.GetRepoGroups()
+}
+
+// CreateGitRepoGroup creates an empty Git repository group
+func (dtm *DevToolsManager) CreateGitRepoGroup(name, description string) (GitRepoGroup, error) {
+	return dtm.gitRepoManager.CreateRepoGroup(name, description)
+}
+
+// DeleteGitRepoGroup deletes a Git repository group
+func (dtm *DevToolsManager) DeleteGitRepoGroup(groupID string) error {
+	return dtm.gitRepoManager.DeleteRepoGroup(groupID)
+}
+
+// AddGitReposToGroup adds Git repositories to a group
+func (dtm *DevToolsManager) AddGitReposToGroup(groupID string, repoIDs []string) (GitRepoGroup, error) {
+	return dtm.gitRepoManager.AddReposToGroup(groupID, repoIDs)
+}
+
+// RemoveGitReposFromGroup removes Git repositories from a group
+func (dtm *DevToolsManager) RemoveGitReposFromGroup(groupID string, repoIDs []string) (GitRepoGroup, error) {
+	return dtm.gitRepoManager.RemoveReposFromGroup(groupID, repoIDs)
+}
+
+// QueryGitRepos returns the Git repositories matching a query
+func (dtm *DevToolsManager) QueryGitRepos(query GitRepoQuery) ([]GitRepoInfo, error) {
+	return dtm.gitRepoManager.QueryRepos(query)
+}
+
+// GetGitBlame returns the commit that last changed each line of a file
+func (dtm *DevToolsManager) GetGitBlame(repoID, path, rev string) (GitBlame, error) {
+	return dtm.gitRepoManager.GetBlame(repoID, path, rev)
+}
+
+// GetGitFileHistory returns the commits that changed a file, following renames
+func (dtm *DevToolsManager) GetGitFileHistory(repoID, path string) ([]GitFileRevision, error) {
+	return dtm.gitRepoManager.GetFileHistory(repoID, path)
+}
+
+// ListGitStashes returns the stash entries of a Git repository
+func (dtm *DevToolsManager) ListGitStashes(repoID string) ([]GitStash, error) {
+	return dtm.gitRepoManager.ListStashes(repoID)
+}
+
+// ShowGitStash returns the files and diffs of a stash entry
+func (dtm *DevToolsManager) ShowGitStash(repoID, hash string) (GitStashDetail, error) {
+	return dtm.gitRepoManager.ShowStash(repoID, hash)
+}
+
+// ApplyGitStash applies a stash entry and keeps it
+func (dtm *DevToolsManager) ApplyGitStash(repoID, hash string) error {
+	return dtm.gitRepoManager.ApplyStash(repoID, hash)
+}
+
+// PopGitStash applies a stash entry and removes it
+func (dtm *DevToolsManager) PopGitStash(repoID, hash string) error {
+	return dtm.gitRepoManager.PopStash(repoID, hash)
+}
+
+// DropGitStash removes a stash entry
+func (dtm *DevToolsManager) DropGitStash(repoID, hash string) error {
+	return dtm.gitRepoManager.DropStash(repoID, hash)
+}
+
+// CreateGitStash stashes the local changes of a Git repository
+func (dtm *DevToolsManager) CreateGitStash(repoID, message string, includeUntracked bool) (GitStash, error) {
+	return dtm.gitRepoManager.CreateStash(repoID, message, includeUntracked)
+}
+
+// ListGitWorktrees returns the worktrees of a Git repository
+func (dtm *DevToolsManager) ListGitWorktrees(repoID string) ([]GitWorktree, error) {
+	return dtm.gitRepoManager.ListWorktrees(repoID)
+}
+
+// CreateGitWorktree checks out a branch of a Git repository in a new worktree
+func (dtm *DevToolsManager) CreateGitWorktree(repoID string, options GitWorktreeOptions) (GitWorktree, error) {
+	return dtm.gitRepoManager.CreateWorktree(repoID, options)
+}
+
+// RemoveGitWorktree removes a linked worktree of a Git repository
+func (dtm *DevToolsManager) RemoveGitWorktree(repoID, path string, force bool) error {
+	return dtm.gitRepoManager.RemoveWorktree(repoID, path, force)
+}
+
+// PruneGitWorktrees deletes the records of worktrees whose directory is gone
+func (dtm *DevToolsManager) PruneGitWorktrees(repoID string) ([]GitWorktree, error) {
+	return dtm.gitRepoManager.PruneWorktrees(repoID)
+}
+
 // GetGitRepoManager returns the Git repository manager
 func (dtm *DevToolsManager) GetGitRepoManager() *GitRepoManager {
 	return dtm.gitRepoManager
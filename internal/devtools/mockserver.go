@@ -0,0 +1,446 @@
+package devtools
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockRequestEvent is the Wails event emitted for every request the mock server receives
+const MockRequestEvent = "mockserver:request"
+
+// maxMockLogEntries caps the request log of the mock server
+const maxMockLogEntries = 1000
+
+// MockMatch narrows a route to requests with specific query parameters,
+// headers or body content. Empty fields match anything.
+type MockMatch struct {
+	Query        map[string]string `json:"query,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	BodyContains string            `json:"bodyContains,omitempty"`
+}
+
+// MockRoute is a stubbed response for requests matching a method and path pattern.
+// Path patterns may contain :name segments and a trailing * wildcard.
+type MockRoute struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name,omitempty"`
+	Method     string              `json:"method"` // empty or "*" matches any method
+	Path       string              `json:"path"`
+	Match      MockMatch           `json:"match"`
+	StatusCode int                 `json:"statusCode"`
+	Headers    map[string][]string `json:"headers"`
+	Body       string              `json:"body"`
+	BodyBase64 string              `json:"bodyBase64,omitempty"` // used instead of Body for binary content
+	DelayMs    int                 `json:"delayMs"`
+	Enabled    bool                `json:"enabled"`
+}
+
+// MockRequestLog records a request received by the mock server
+type MockRequestLog struct {
+	ID             string              `json:"id"`
+	Timestamp      time.Time           `json:"timestamp"`
+	Method         string              `json:"method"`
+	Path           string              `json:"path"`
+	Query          string              `json:"query,omitempty"`
+	Headers        map[string][]string `json:"headers"`
+	Body           string              `json:"body"`
+	MatchedRouteID string              `json:"matchedRouteId,omitempty"`
+	StatusCode     int                 `json:"statusCode"`
+}
+
+// MockServerInfo describes the state of the mock server
+type MockServerInfo struct {
+	Running    bool   `json:"running"`
+	Port       int    `json:"port"`
+	URL        string `json:"url,omitempty"`
+	StartTime  string `json:"startTime,omitempty"`
+	RouteCount int    `json:"routeCount"`
+}
+
+// MockServer serves stubbed routes on a local port
+type MockServer struct {
+	routes    []*MockRoute
+	requests  []MockRequestLog
+	server    *http.Server
+	port      int
+	startTime time.Time
+	emit      EventEmitter
+	mutex     sync.Mutex
+}
+
+var (
+	mockServer     *MockServer
+	mockServerOnce sync.Once
+)
+
+// GetMockServer returns the singleton instance of MockServer
+func GetMockServer() *MockServer {
+	mockServerOnce.Do(func() {
+		mockServer = &MockServer{
+			routes:   []*MockRoute{},
+			requests: []MockRequestLog{},
+		}
+	})
+	return mockServer
+}
+
+// SetEventEmitter sets the function used to push received requests to the frontend
+func (ms *MockServer) SetEventEmitter(emit EventEmitter) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.emit = emit
+}
+
+// Start starts serving routes on a local port
+func (ms *MockServer) Start(port int) (MockServerInfo, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if ms.server != nil {
+		return ms.info(), fmt.Errorf("mock server is already running on port %d", ms.port)
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return ms.info(), fmt.Errorf("error listening on port %d: %v", port, err)
+	}
+
+	ms.server = &http.Server{Handler: http.HandlerFunc(ms.serveHTTP)}
+	ms.port = listener.Addr().(*net.TCPAddr).Port
+	ms.startTime = time.Now()
+
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Mock server stopped: %v\n", err)
+		}
+	}(ms.server)
+
+	return ms.info(), nil
+}
+
+// Stop stops the mock server
+func (ms *MockServer) Stop() (MockServerInfo, error) {
+	ms.mutex.Lock()
+	server := ms.server
+	ms.server = nil
+	ms.mutex.Unlock()
+
+	if server == nil {
+		return ms.GetInfo(), fmt.Errorf("mock server is not running")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+	}
+
+	return ms.GetInfo(), nil
+}
+
+// GetInfo returns the state of the mock server
+func (ms *MockServer) GetInfo() MockServerInfo {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	return ms.info()
+}
+
+// info returns the state of the mock server; the caller must hold the mutex
+func (ms *MockServer) info() MockServerInfo {
+	info := MockServerInfo{
+		Running:    ms.server != nil,
+		Port:       ms.port,
+		RouteCount: len(ms.routes),
+	}
+	if info.Running {
+		info.URL = fmt.Sprintf("http://localhost:%d", ms.port)
+		info.StartTime = ms.startTime.Format(time.RFC3339)
+	}
+	return info
+}
+
+// GetRoutes returns all routes in match order
+func (ms *MockServer) GetRoutes() []MockRoute {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	routes := make([]MockRoute, 0, len(ms.routes))
+	for _, route := range ms.routes {
+		routes = append(routes, *route)
+	}
+	return routes
+}
+
+// AddRoute adds a route, or replaces the route with the same ID. New routes
+// are enabled; a replaced route keeps the Enabled value it is given.
+func (ms *MockServer) AddRoute(route MockRoute) (MockRoute, error) {
+	if route.Path == "" || !strings.HasPrefix(route.Path, "/") {
+		return MockRoute{}, fmt.Errorf("route path must start with /")
+	}
+	if route.BodyBase64 != "" {
+		if _, err := base64.StdEncoding.DecodeString(route.BodyBase64); err != nil {
+			return MockRoute{}, fmt.Errorf("invalid base64 body: %v", err)
+		}
+	}
+
+	// Generate a unique ID if not provided
+	if route.ID == "" {
+		route.ID = fmt.Sprintf("route-%d", time.Now().UnixNano())
+	}
+	if route.StatusCode == 0 {
+		route.StatusCode = http.StatusOK
+	}
+	route.Method = strings.ToUpper(route.Method)
+
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	for i, existing := range ms.routes {
+		if existing.ID == route.ID {
+			ms.routes[i] = &route
+			return route, nil
+		}
+	}
+	route.Enabled = true
+	ms.routes = append(ms.routes, &route)
+	return route, nil
+}
+
+// RemoveRoute removes a route
+func (ms *MockServer) RemoveRoute(routeID string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	for i, route := range ms.routes {
+		if route.ID == routeID {
+			ms.routes = append(ms.routes[:i], ms.routes[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("route with ID %s not found", routeID)
+}
+
+// AddRouteFromResponse creates a route that replays resp for requests like req
+func (ms *MockServer) AddRouteFromResponse(req APIRequest, resp APIResponse) (MockRoute, error) {
+	if resp.StatusCode == 0 {
+		return MockRoute{}, fmt.Errorf("response has no status, it can't be replayed")
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return MockRoute{}, fmt.Errorf("invalid request URL: %v", err)
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	route := MockRoute{
+		Name:       fmt.Sprintf("%s %s", req.Method, path),
+		Method:     string(req.Method),
+		Path:       path,
+		StatusCode: resp.StatusCode,
+		Headers:    make(map[string][]string),
+		Body:       resp.Body,
+		BodyBase64: resp.BodyBase64,
+	}
+
+	// Only match the same query parameters the original request used
+	if query := u.Query(); len(query) > 0 {
+		route.Match.Query = make(map[string]string)
+		for key := range query {
+			route.Match.Query[key] = query.Get(key)
+		}
+	}
+
+	// Drop headers describing the original transfer. Content-Encoding is only
+	// present when the stored body wasn't decoded, so it still describes the body.
+	for key, values := range resp.Headers {
+		switch http.CanonicalHeaderKey(key) {
+		case "Content-Length", "Transfer-Encoding", "Connection", "Date":
+			continue
+		}
+		route.Headers[key] = append([]string(nil), values...)
+	}
+
+	// Bodies that were saved to disk are embedded in the route
+	if resp.SavedPath != "" {
+		data, err := os.ReadFile(resp.SavedPath)
+		if err != nil {
+			return MockRoute{}, fmt.Errorf("error reading saved response body: %v", err)
+		}
+		route.Body = ""
+		route.BodyBase64 = base64.StdEncoding.EncodeToString(data)
+	}
+
+	return ms.AddRoute(route)
+}
+
+// GetRequestLog returns the requests received, oldest first
+func (ms *MockServer) GetRequestLog() []MockRequestLog {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	return append([]MockRequestLog(nil), ms.requests...)
+}
+
+// ClearRequestLog discards the request log
+func (ms *MockServer) ClearRequestLog() {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.requests = []MockRequestLog{}
+}
+
+// serveHTTP answers a request with the best matching route
+func (ms *MockServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(io.LimitReader(r.Body, 10<<20))
+
+	ms.mutex.Lock()
+	var matched *MockRoute
+	bestScore := -1
+	for _, route := range ms.routes {
+		if score, ok := route.matches(r, body); ok && score > bestScore {
+			matched, bestScore = route, score
+		}
+	}
+	var route MockRoute
+	if matched != nil {
+		route = *matched
+	}
+	emit := ms.emit
+	ms.mutex.Unlock()
+
+	entry := MockRequestLog{
+		ID:        fmt.Sprintf("req-%d", time.Now().UnixNano()),
+		Timestamp: time.Now(),
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Query:     r.URL.RawQuery,
+		Headers:   r.Header.Clone(),
+		Body:      string(body),
+	}
+
+	if matched == nil {
+		entry.StatusCode = http.StatusNotFound
+		ms.logRequest(entry, emit)
+		http.Error(w, fmt.Sprintf("No mock route matches %s %s", r.Method, r.URL.Path), http.StatusNotFound)
+		return
+	}
+
+	entry.MatchedRouteID = route.ID
+	entry.StatusCode = route.StatusCode
+	ms.logRequest(entry, emit)
+
+	if route.DelayMs > 0 {
+		select {
+		case <-time.After(time.Duration(route.DelayMs) * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	for key, values := range route.Headers {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(route.StatusCode)
+
+	if route.BodyBase64 != "" {
+		data, _ := base64.StdEncoding.DecodeString(route.BodyBase64)
+		w.Write(data)
+	} else {
+		io.WriteString(w, route.Body)
+	}
+}
+
+// logRequest appends an entry to the request log and emits it to the frontend
+func (ms *MockServer) logRequest(entry MockRequestLog, emit EventEmitter) {
+	ms.mutex.Lock()
+	ms.requests = append(ms.requests, entry)
+	if len(ms.requests) > maxMockLogEntries {
+		ms.requests = ms.requests[len(ms.requests)-maxMockLogEntries:]
+	}
+	ms.mutex.Unlock()
+
+	if emit != nil {
+		emit(MockRequestEvent, entry)
+	}
+}
+
+// matches reports whether the route applies to a request. The score ranks
+// matching routes so that more specific routes win.
+func (route *MockRoute) matches(r *http.Request, body []byte) (int, bool) {
+	if !route.Enabled {
+		return 0, false
+	}
+	if route.Method != "" && route.Method != "*" && route.Method != r.Method {
+		return 0, false
+	}
+
+	score, ok := matchPath(route.Path, r.URL.Path)
+	if !ok {
+		return 0, false
+	}
+
+	query := r.URL.Query()
+	for key, value := range route.Match.Query {
+		if query.Get(key) != value {
+			return 0, false
+		}
+		score += 100
+	}
+	for key, value := range route.Match.Headers {
+		if r.Header.Get(key) != value {
+			return 0, false
+		}
+		score += 100
+	}
+	if route.Match.BodyContains != "" {
+		if !strings.Contains(string(body), route.Match.BodyContains) {
+			return 0, false
+		}
+		score += 100
+	}
+	if route.Method != "" && route.Method != "*" {
+		score++
+	}
+	return score, true
+}
+
+// matchPath matches a path against a pattern with :name segments and a trailing
+// * wildcard. Literal segments score higher than parameters.
+func matchPath(pattern, path string) (int, bool) {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+
+	score := 0
+	for i, segment := range patternSegments {
+		if segment == "*" && i == len(patternSegments)-1 {
+			return score, true
+		}
+		if i >= len(pathSegments) {
+			return 0, false
+		}
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			score += 2
+		case segment == pathSegments[i]:
+			score += 10
+		default:
+			return 0, false
+		}
+	}
+	if len(pathSegments) != len(patternSegments) {
+		return 0, false
+	}
+	return score, true
+}
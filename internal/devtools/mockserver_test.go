@@ -0,0 +1,95 @@
+package devtools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// encodedResponse returns a response with a body in the given Content-Encoding
+func encodedResponse(encoding string, body []byte) *http.Response {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Content-Encoding", encoding)
+	header.Set("Content-Length", "123")
+	return &http.Response{StatusCode: 200, Status: "200 OK", Proto: "HTTP/1.1", Header: header}
+}
+
+func TestRecordResponseDecodesGzip(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte(`{"ok": true}`))
+	writer.Close()
+
+	var response APIResponse
+	recordResponse(&response, encodedResponse("gzip", nil), compressed.Bytes(), false)
+	if response.Body != `{"ok": true}` || response.IsBinary {
+		t.Errorf("body = %q, binary %v, want the decoded JSON", response.Body, response.IsBinary)
+	}
+	if _, exists := response.Headers["Content-Encoding"]; exists {
+		t.Errorf("headers = %v, want no Content-Encoding for a decoded body", response.Headers)
+	}
+}
+
+func TestRecordResponseKeepsOtherEncodings(t *testing.T) {
+	encoded := []byte{0x0b, 0x05, 0x80, 0x7b, 0x7d, 0x03}
+
+	var response APIResponse
+	recordResponse(&response, encodedResponse("br", nil), encoded, false)
+	if !response.IsBinary || response.BodyBase64 != base64.StdEncoding.EncodeToString(encoded) {
+		t.Errorf("response = %+v, want the encoded bytes as binary", response)
+	}
+	if got := response.Headers["Content-Encoding"]; len(got) != 1 || got[0] != "br" {
+		t.Errorf("Content-Encoding = %v, want br", got)
+	}
+}
+
+func TestAddRouteFromResponseKeepsContentEncoding(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte("still compressed"))
+	writer.Close()
+
+	ms := &MockServer{routes: []*MockRoute{}, requests: []MockRequestLog{}}
+	info, err := ms.Start(0)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ms.Stop()
+
+	// A response the client didn't decode, as applyResponseBody stores it
+	resp := APIResponse{
+		StatusCode: 200,
+		Headers: map[string][]string{
+			"Content-Encoding": {"gzip"},
+			"Content-Length":   {"999"},
+			"Content-Type":     {"text/plain"},
+		},
+		IsBinary:   true,
+		BodyBase64: base64.StdEncoding.EncodeToString(compressed.Bytes()),
+	}
+	route, err := ms.AddRouteFromResponse(APIRequest{Method: "GET", URL: "http://example.com/data"}, resp)
+	if err != nil {
+		t.Fatalf("AddRouteFromResponse: %v", err)
+	}
+	if _, exists := route.Headers["Content-Length"]; exists {
+		t.Errorf("route headers = %v, want no Content-Length", route.Headers)
+	}
+
+	// The client decodes the body again, which fails unless the header is served
+	served, err := http.Get(info.URL + "/data")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer served.Body.Close()
+	body, err := io.ReadAll(served.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	if string(body) != "still compressed" {
+		t.Errorf("body = %q, want the decoded content", body)
+	}
+}
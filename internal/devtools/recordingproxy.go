@@ -0,0 +1,671 @@
+package devtools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxyRecordEvent is the Wails event emitted for every exchange the recording proxy stores
+const ProxyRecordEvent = "proxy:record"
+
+// maxProxyRecords caps the number of exchanges kept by the recording proxy
+const maxProxyRecords = 1000
+
+// defaultProxyBodySize is the default number of body bytes stored per request or response
+const defaultProxyBodySize = 1 << 20
+
+// ProxyConfig configures the recording proxy. Requests with an absolute URL are
+// forwarded as-is; other requests are sent to Target when it is set.
+type ProxyConfig struct {
+	Port         int      `json:"port"`
+	Target       string   `json:"target,omitempty"`      // upstream base URL for reverse proxying
+	AllowConnect bool     `json:"allowConnect"`          // tunnel CONNECT requests (contents are not recorded)
+	HostFilters  []string `json:"hostFilters,omitempty"` // hosts to record, "*.example.com" matches subdomains
+	PathFilters  []string `json:"pathFilters,omitempty"` // path prefixes to record
+	MaxBodySize  int      `json:"maxBodySize"`           // bytes stored per body, 0 for the default
+}
+
+// ProxyRecord is a request/response pair captured by the recording proxy
+type ProxyRecord struct {
+	ID                string      `json:"id"`
+	Timestamp         time.Time   `json:"timestamp"`
+	Request           APIRequest  `json:"request"`
+	Response          APIResponse `json:"response"`
+	Tunnel            bool        `json:"tunnel"`
+	RequestTruncated  bool        `json:"requestTruncated"`
+	ResponseTruncated bool        `json:"responseTruncated"`
+	WithheldHeaders   []string    `json:"withheldHeaders,omitempty"` // credential headers left out of Request
+
+	// credentials holds the values of WithheldHeaders. They stay in memory
+	// with the record and are only written to disk by SaveRecordCredentials.
+	credentials map[string]string
+}
+
+// ProxyInfo describes the state of the recording proxy
+type ProxyInfo struct {
+	Running     bool        `json:"running"`
+	Port        int         `json:"port"`
+	URL         string      `json:"url,omitempty"`
+	StartTime   string      `json:"startTime,omitempty"`
+	RecordCount int         `json:"recordCount"`
+	Config      ProxyConfig `json:"config"`
+}
+
+// RecordingProxy is a local forward/reverse HTTP proxy that records the traffic it carries
+type RecordingProxy struct {
+	config    ProxyConfig
+	target    *url.URL
+	records   []ProxyRecord
+	server    *http.Server
+	proxy     *httputil.ReverseProxy
+	port      int
+	startTime time.Time
+	emit      EventEmitter
+	mutex     sync.Mutex
+}
+
+// proxyExchange tracks a request while it passes through the proxy
+type proxyExchange struct {
+	start       time.Time
+	request     APIRequest
+	credentials map[string]string // credential headers, kept out of request
+	requestBody *cappedBuffer
+	once        sync.Once
+}
+
+type proxyExchangeKey struct{}
+
+// cappedBuffer stores up to limit bytes and silently drops the rest
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write implements io.Writer; it never fails so it can be used with io.TeeReader
+func (cb *cappedBuffer) Write(p []byte) (int, error) {
+	room := cb.limit - cb.buf.Len()
+	if room < len(p) {
+		cb.truncated = true
+		if room > 0 {
+			cb.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	cb.buf.Write(p)
+	return len(p), nil
+}
+
+// recordingBody copies a body into a capped buffer and calls done when closed
+type recordingBody struct {
+	io.Reader
+	closer io.Closer
+	done   func()
+}
+
+// Close closes the underlying body and finishes the recording
+func (rb *recordingBody) Close() error {
+	err := rb.closer.Close()
+	rb.done()
+	return err
+}
+
+var (
+	recordingProxy     *RecordingProxy
+	recordingProxyOnce sync.Once
+)
+
+// GetRecordingProxy returns the singleton instance of RecordingProxy
+func GetRecordingProxy() *RecordingProxy {
+	recordingProxyOnce.Do(func() {
+		recordingProxy = &RecordingProxy{
+			records: []ProxyRecord{},
+		}
+	})
+	return recordingProxy
+}
+
+// SetEventEmitter sets the function used to push recorded exchanges to the frontend
+func (rp *RecordingProxy) SetEventEmitter(emit EventEmitter) {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+	rp.emit = emit
+}
+
+// Start starts the proxy on a local port
+func (rp *RecordingProxy) Start(config ProxyConfig) (ProxyInfo, error) {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+
+	if rp.server != nil {
+		return rp.info(), fmt.Errorf("recording proxy is already running on port %d", rp.port)
+	}
+
+	var target *url.URL
+	if config.Target != "" {
+		parsed, err := url.Parse(config.Target)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return rp.info(), fmt.Errorf("invalid target URL: %s", config.Target)
+		}
+		target = parsed
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultProxyBodySize
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", config.Port))
+	if err != nil {
+		return rp.info(), fmt.Errorf("error listening on port %d: %v", config.Port, err)
+	}
+
+	// Don't honour proxy environment variables, they may point back at us
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+
+	rp.config = config
+	rp.target = target
+	rp.proxy = &httputil.ReverseProxy{
+		Rewrite:        rp.rewrite,
+		Transport:      transport,
+		ModifyResponse: rp.modifyResponse,
+		ErrorHandler:   rp.handleError,
+		FlushInterval:  -1,
+	}
+	rp.server = &http.Server{Handler: http.HandlerFunc(rp.serveHTTP)}
+	rp.port = listener.Addr().(*net.TCPAddr).Port
+	rp.startTime = time.Now()
+
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Recording proxy stopped: %v\n", err)
+		}
+	}(rp.server)
+
+	return rp.info(), nil
+}
+
+// Stop stops the proxy
+func (rp *RecordingProxy) Stop() (ProxyInfo, error) {
+	rp.mutex.Lock()
+	server := rp.server
+	rp.server = nil
+	rp.mutex.Unlock()
+
+	if server == nil {
+		return rp.GetInfo(), fmt.Errorf("recording proxy is not running")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+	}
+
+	return rp.GetInfo(), nil
+}
+
+// GetInfo returns the state of the proxy
+func (rp *RecordingProxy) GetInfo() ProxyInfo {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+	return rp.info()
+}
+
+// info returns the state of the proxy; the caller must hold the mutex
+func (rp *RecordingProxy) info() ProxyInfo {
+	info := ProxyInfo{
+		Running:     rp.server != nil,
+		Port:        rp.port,
+		RecordCount: len(rp.records),
+		Config:      rp.config,
+	}
+	if info.Running {
+		info.URL = fmt.Sprintf("http://localhost:%d", rp.port)
+		info.StartTime = rp.startTime.Format(time.RFC3339)
+	}
+	return info
+}
+
+// GetRecords returns the recorded exchanges, oldest first
+func (rp *RecordingProxy) GetRecords() []ProxyRecord {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+	return append([]ProxyRecord(nil), rp.records...)
+}
+
+// GetRecord returns a recorded exchange
+func (rp *RecordingProxy) GetRecord(recordID string) (ProxyRecord, error) {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+
+	for _, record := range rp.records {
+		if record.ID == recordID {
+			return record, nil
+		}
+	}
+	return ProxyRecord{}, fmt.Errorf("record with ID %s not found", recordID)
+}
+
+// SaveRecordCredentials stores the Basic or Bearer credentials captured with a
+// record in the secret store, under the reference its request's auth uses, and
+// returns the request so it can be saved
+func (rp *RecordingProxy) SaveRecordCredentials(recordID string) (APIRequest, error) {
+	record, err := rp.GetRecord(recordID)
+	if err != nil {
+		return APIRequest{}, err
+	}
+
+	auth, secrets := recordedAuth(record.credentials["Authorization"])
+	if auth == nil {
+		return APIRequest{}, fmt.Errorf("record %s has no Basic or Bearer credentials", recordID)
+	}
+	if err := GetSecretStore().Set(auth.SecretRef, secrets); err != nil {
+		return APIRequest{}, err
+	}
+	return record.Request, nil
+}
+
+// replayRequest returns the recorded request with its withheld credential headers put back
+func (record ProxyRecord) replayRequest() APIRequest {
+	req := record.Request
+	if len(record.credentials) == 0 {
+		return req
+	}
+
+	req.Headers = make(map[string]string, len(record.Request.Headers)+len(record.credentials))
+	for key, value := range record.Request.Headers {
+		req.Headers[key] = value
+	}
+	for key, value := range record.credentials {
+		req.Headers[key] = value
+	}
+	// The original header is sent instead of the secret it would be saved as
+	if _, exists := record.credentials["Authorization"]; exists {
+		req.Auth = nil
+	}
+	return req
+}
+
+// DeleteRecord removes a recorded exchange
+func (rp *RecordingProxy) DeleteRecord(recordID string) error {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+
+	for i, record := range rp.records {
+		if record.ID == recordID {
+			rp.records = append(rp.records[:i], rp.records[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("record with ID %s not found", recordID)
+}
+
+// ClearRecords discards all recorded exchanges
+func (rp *RecordingProxy) ClearRecords() {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+	rp.records = []ProxyRecord{}
+}
+
+// serveHTTP tunnels CONNECT requests and proxies everything else
+func (rp *RecordingProxy) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	rp.mutex.Lock()
+	config := rp.config
+	target := rp.target
+	proxy := rp.proxy
+	rp.mutex.Unlock()
+
+	if r.Method == http.MethodConnect {
+		if !config.AllowConnect {
+			http.Error(w, "CONNECT tunnels are disabled", http.StatusMethodNotAllowed)
+			return
+		}
+		rp.tunnel(w, r, config)
+		return
+	}
+
+	if !r.URL.IsAbs() && target == nil {
+		http.Error(w, "No target configured for reverse proxying; use this address as an HTTP proxy instead", http.StatusBadGateway)
+		return
+	}
+
+	// Only capture exchanges that pass the filters
+	host, path := r.URL.Hostname(), r.URL.Path
+	if !r.URL.IsAbs() {
+		host, path = target.Hostname(), singleJoiningSlash(target.Path, r.URL.Path)
+	}
+	if !config.shouldRecord(host, path) {
+		proxy.ServeHTTP(w, r)
+		return
+	}
+
+	exchange := &proxyExchange{
+		start:       time.Now(),
+		requestBody: &cappedBuffer{limit: config.MaxBodySize},
+	}
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(r.Body, exchange.requestBody), r.Body}
+	}
+
+	proxy.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), proxyExchangeKey{}, exchange)))
+}
+
+// rewrite points the outgoing request at its upstream and captures its headers
+func (rp *RecordingProxy) rewrite(pr *httputil.ProxyRequest) {
+	if !pr.In.URL.IsAbs() {
+		rp.mutex.Lock()
+		target := rp.target
+		rp.mutex.Unlock()
+
+		pr.SetURL(target)
+		pr.SetXForwarded()
+	}
+
+	exchange, ok := pr.In.Context().Value(proxyExchangeKey{}).(*proxyExchange)
+	if !ok {
+		return
+	}
+
+	exchange.request = APIRequest{
+		URL:     pr.Out.URL.String(),
+		Method:  RequestMethod(pr.Out.Method),
+		Headers: make(map[string]string),
+	}
+	// Leave out headers the API tester sets itself when the request is replayed
+	for key, values := range pr.Out.Header {
+		if strings.HasPrefix(key, "X-Forwarded-") || strings.HasPrefix(key, "Proxy-") ||
+			key == "Content-Length" || key == "Accept-Encoding" {
+			continue
+		}
+		value := strings.Join(values, ", ")
+
+		// Credentials are kept out of the recorded request definition. Basic
+		// and Bearer credentials become an auth block referring to the secret
+		// they are saved as.
+		if key == "Authorization" || key == "Cookie" {
+			if exchange.credentials == nil {
+				exchange.credentials = make(map[string]string)
+			}
+			exchange.credentials[key] = value
+			if key == "Authorization" {
+				exchange.request.Auth, _ = recordedAuth(value)
+			}
+			continue
+		}
+		exchange.request.Headers[key] = value
+	}
+}
+
+// recordedAuth returns the auth block and secrets for Basic and Bearer
+// credentials, or nil for other schemes. The secret reference is derived from
+// the credentials so repeated requests share it.
+func recordedAuth(header string) (*APIAuth, AuthSecrets) {
+	scheme, credentials, _ := strings.Cut(header, " ")
+	credentials = strings.TrimSpace(credentials)
+
+	hash := sha256.Sum256([]byte(header))
+	auth := &APIAuth{SecretRef: "recorded-" + hex.EncodeToString(hash[:8])}
+	var secrets AuthSecrets
+	switch {
+	case strings.EqualFold(scheme, "Bearer") && credentials != "":
+		auth.Type = AuthBearer
+		secrets.Token = credentials
+	case strings.EqualFold(scheme, "Basic"):
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			return nil, AuthSecrets{}
+		}
+		username, password, found := strings.Cut(string(decoded), ":")
+		if !found {
+			return nil, AuthSecrets{}
+		}
+		auth.Type = AuthBasic
+		auth.Username = username
+		secrets.Password = password
+	default:
+		return nil, AuthSecrets{}
+	}
+	return auth, secrets
+}
+
+// modifyResponse wraps the response body so the exchange is recorded once it has been relayed
+func (rp *RecordingProxy) modifyResponse(resp *http.Response) error {
+	exchange, ok := resp.Request.Context().Value(proxyExchangeKey{}).(*proxyExchange)
+	if !ok {
+		return nil
+	}
+
+	rp.mutex.Lock()
+	limit := rp.config.MaxBodySize
+	rp.mutex.Unlock()
+
+	responseBody := &cappedBuffer{limit: limit}
+	resp.Body = &recordingBody{
+		Reader: io.TeeReader(resp.Body, responseBody),
+		closer: resp.Body,
+		done: func() {
+			exchange.once.Do(func() { rp.record(exchange, resp, responseBody, nil) })
+		},
+	}
+	return nil
+}
+
+// handleError answers with 502 when the upstream can't be reached and records the failure
+func (rp *RecordingProxy) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if exchange, ok := r.Context().Value(proxyExchangeKey{}).(*proxyExchange); ok {
+		exchange.once.Do(func() { rp.record(exchange, nil, nil, err) })
+	}
+	http.Error(w, fmt.Sprintf("Proxy error: %v", err), http.StatusBadGateway)
+}
+
+// record converts an exchange to API tester format and stores it
+func (rp *RecordingProxy) record(exchange *proxyExchange, resp *http.Response, responseBody *cappedBuffer, proxyErr error) {
+	record := ProxyRecord{
+		ID:               fmt.Sprintf("rec-%d", time.Now().UnixNano()),
+		Timestamp:        exchange.start,
+		Request:          exchange.request,
+		RequestTruncated: exchange.requestBody.truncated,
+		credentials:      exchange.credentials,
+	}
+	for key := range exchange.credentials {
+		record.WithheldHeaders = append(record.WithheldHeaders, key)
+	}
+	sort.Strings(record.WithheldHeaders)
+
+	// Keep text request bodies so the request can be replayed or edited
+	if exchange.requestBody.buf.Len() > 0 {
+		mediaType, _, _ := mime.ParseMediaType(exchange.request.Headers["Content-Type"])
+		body := exchange.requestBody.buf.Bytes()
+		if isTextContent(mediaType, body) {
+			record.Request.Body = string(body)
+			record.Request.BodyType = BodyRaw
+			if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+				record.Request.BodyType = BodyJSON
+			}
+		}
+	}
+
+	record.Response.Duration = time.Since(exchange.start).Milliseconds()
+	if proxyErr != nil {
+		record.Response.Error = proxyErr.Error()
+	} else {
+		record.ResponseTruncated = responseBody.truncated
+		recordResponse(&record.Response, resp, responseBody.buf.Bytes(), responseBody.truncated)
+	}
+
+	rp.store(record)
+}
+
+// recordResponse fills in a recorded response, decoding gzip bodies when they
+// are complete. As with the HTTP client, Content-Encoding is only kept when the
+// body is still encoded.
+func recordResponse(response *APIResponse, resp *http.Response, body []byte, truncated bool) {
+	response.StatusCode = resp.StatusCode
+	response.Status = resp.Status
+	response.Protocol = resp.Proto
+	response.Headers = convertHeaders(resp.Header)
+	response.Cookies = convertCookies(resp.Cookies())
+	response.Size = int64(len(body))
+
+	encoded := resp.Header.Get("Content-Encoding") != ""
+	if !truncated && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		if reader, err := gzip.NewReader(bytes.NewReader(body)); err == nil {
+			if decoded, err := io.ReadAll(reader); err == nil {
+				body = decoded
+				encoded = false
+				delete(response.Headers, "Content-Encoding")
+				delete(response.Headers, "Content-Length")
+			}
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	response.ContentType = mediaType
+	if response.ContentType == "" && len(body) > 0 {
+		response.ContentType = http.DetectContentType(body)
+	}
+
+	if !encoded && isTextContent(mediaType, body) {
+		response.Body = string(body)
+	} else if len(body) > 0 {
+		response.IsBinary = true
+		response.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+}
+
+// store appends a record and emits it to the frontend
+func (rp *RecordingProxy) store(record ProxyRecord) {
+	rp.mutex.Lock()
+	rp.records = append(rp.records, record)
+	if len(rp.records) > maxProxyRecords {
+		rp.records = rp.records[len(rp.records)-maxProxyRecords:]
+	}
+	emit := rp.emit
+	rp.mutex.Unlock()
+
+	if emit != nil {
+		emit(ProxyRecordEvent, record)
+	}
+}
+
+// tunnel relays a CONNECT request to its destination. The traffic is encrypted,
+// so only the tunnel itself is recorded.
+func (rp *RecordingProxy) tunnel(w http.ResponseWriter, r *http.Request, config ProxyConfig) {
+	start := time.Now()
+
+	upstream, err := net.DialTimeout("tcp", r.Host, 10*time.Second)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error connecting to %s: %v", r.Host, err), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "Tunnelling is not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n")
+
+	// Copy in both directions until either side closes
+	var received int64
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(upstream, buffered)
+		if conn, ok := upstream.(*net.TCPConn); ok {
+			conn.CloseWrite()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		received, _ = io.Copy(client, upstream)
+		client.Close()
+	}()
+	wg.Wait()
+	upstream.Close()
+
+	host, _, _ := net.SplitHostPort(r.Host)
+	if !config.shouldRecord(host, "") {
+		return
+	}
+	rp.store(ProxyRecord{
+		ID:        fmt.Sprintf("rec-%d", time.Now().UnixNano()),
+		Timestamp: start,
+		Request: APIRequest{
+			URL:    "https://" + r.Host,
+			Method: RequestMethod(http.MethodConnect),
+		},
+		Response: APIResponse{
+			StatusCode: http.StatusOK,
+			Status:     "200 Connection Established",
+			Size:       received,
+			Duration:   time.Since(start).Milliseconds(),
+		},
+		Tunnel: true,
+	})
+}
+
+// shouldRecord reports whether traffic to host and path passes the filters.
+// An empty path only checks the host filters.
+func (config ProxyConfig) shouldRecord(host, path string) bool {
+	if len(config.HostFilters) > 0 {
+		matched := false
+		for _, filter := range config.HostFilters {
+			filter = strings.ToLower(strings.TrimSpace(filter))
+			host = strings.ToLower(host)
+			if filter == host || (strings.HasPrefix(filter, "*.") && strings.HasSuffix(host, filter[1:])) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(config.PathFilters) > 0 && path != "" {
+		for _, filter := range config.PathFilters {
+			if strings.HasPrefix(path, strings.TrimSpace(filter)) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// singleJoiningSlash joins two URL paths with exactly one slash between them
+func singleJoiningSlash(a, b string) string {
+	switch {
+	case strings.HasSuffix(a, "/") && strings.HasPrefix(b, "/"):
+		return a + b[1:]
+	case !strings.HasSuffix(a, "/") && !strings.HasPrefix(b, "/"):
+		return a + "/" + b
+	}
+	return a + b
+}
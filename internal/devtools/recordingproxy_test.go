@@ -0,0 +1,151 @@
+package devtools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useTestSecretStore replaces the secret store with one backed by a temporary file
+func useTestSecretStore(t *testing.T) *SecretStore {
+	t.Helper()
+
+	previous := GetSecretStore()
+	store := &SecretStore{secrets: make(map[string]AuthSecrets), path: filepath.Join(t.TempDir(), "secrets.json")}
+	secretStore = store
+	t.Cleanup(func() { secretStore = previous })
+	return store
+}
+
+// recordThroughProxy sends a request with credentials through a recording
+// proxy in front of upstream and returns the proxy and the record
+func recordThroughProxy(t *testing.T, upstream string, header http.Header) (*RecordingProxy, ProxyRecord) {
+	t.Helper()
+
+	rp := &RecordingProxy{records: []ProxyRecord{}}
+	info, err := rp.Start(ProxyConfig{Target: upstream})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { rp.Stop() })
+
+	req, _ := http.NewRequest("GET", info.URL+"/private", nil)
+	req.Header = header
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request through proxy: %v", err)
+	}
+	resp.Body.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(rp.GetRecords()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the exchange was not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return rp, rp.GetRecords()[0]
+}
+
+// credentialServer answers 200 only when the request carries the expected credentials
+func credentialServer(t *testing.T) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" || r.Header.Get("Cookie") != "session=abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestRecordingProxyWithholdsCredentials(t *testing.T) {
+	store := useTestSecretStore(t)
+	header := http.Header{"Authorization": {"Bearer s3cret"}, "Cookie": {"session=abc"}, "X-Trace": {"1"}}
+	rp, record := recordThroughProxy(t, credentialServer(t), header)
+
+	if record.Response.StatusCode != http.StatusOK {
+		t.Fatalf("recorded status = %d, want the proxied request to carry its credentials", record.Response.StatusCode)
+	}
+	if _, exists := record.Request.Headers["Authorization"]; exists {
+		t.Errorf("recorded headers = %v, want no Authorization", record.Request.Headers)
+	}
+	if _, exists := record.Request.Headers["Cookie"]; exists {
+		t.Errorf("recorded headers = %v, want no Cookie", record.Request.Headers)
+	}
+	if record.Request.Headers["X-Trace"] != "1" {
+		t.Errorf("recorded headers = %v, want other headers kept", record.Request.Headers)
+	}
+	if len(record.WithheldHeaders) != 2 || record.WithheldHeaders[0] != "Authorization" || record.WithheldHeaders[1] != "Cookie" {
+		t.Errorf("withheld headers = %v", record.WithheldHeaders)
+	}
+	if record.Request.Auth == nil || record.Request.Auth.Type != AuthBearer || record.Request.Auth.SecretRef == "" {
+		t.Fatalf("auth = %+v, want a bearer auth block", record.Request.Auth)
+	}
+
+	// Nothing is written until the credentials are saved
+	if _, exists := store.Get(record.Request.Auth.SecretRef); exists {
+		t.Errorf("credentials were stored while recording")
+	}
+	if _, err := os.Stat(store.path); !os.IsNotExist(err) {
+		t.Errorf("secrets file exists after recording: %v", err)
+	}
+
+	// Replaying sends the withheld headers from memory
+	if resp := NewAPITester().SendRequest(record.replayRequest()); resp.StatusCode != http.StatusOK {
+		t.Errorf("replay status = %d, error %q, want the original credentials sent", resp.StatusCode, resp.Error)
+	}
+
+	req, err := rp.SaveRecordCredentials(record.ID)
+	if err != nil {
+		t.Fatalf("SaveRecordCredentials: %v", err)
+	}
+	if secrets, exists := store.Get(req.Auth.SecretRef); !exists || secrets.Token != "s3cret" {
+		t.Errorf("stored secrets = %+v, %v, want the bearer token", secrets, exists)
+	}
+
+	rp.ClearRecords()
+	if _, err := rp.SaveRecordCredentials(record.ID); err == nil {
+		t.Errorf("SaveRecordCredentials succeeded for a cleared record")
+	}
+}
+
+func TestRecordingProxyBasicAuth(t *testing.T) {
+	store := useTestSecretStore(t)
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	req.SetBasicAuth("alice", "pa:ss")
+	rp, record := recordThroughProxy(t, credentialServer(t), req.Header)
+
+	auth := record.Request.Auth
+	if auth == nil || auth.Type != AuthBasic || auth.Username != "alice" {
+		t.Fatalf("auth = %+v, want basic auth for alice", auth)
+	}
+	if _, err := rp.SaveRecordCredentials(record.ID); err != nil {
+		t.Fatalf("SaveRecordCredentials: %v", err)
+	}
+	if secrets, _ := store.Get(auth.SecretRef); secrets.Password != "pa:ss" {
+		t.Errorf("stored password = %q", secrets.Password)
+	}
+}
+
+func TestRecordingProxyOtherAuthSchemes(t *testing.T) {
+	useTestSecretStore(t)
+	header := http.Header{"Authorization": {"Digest username=\"alice\""}}
+	rp, record := recordThroughProxy(t, credentialServer(t), header)
+
+	if record.Request.Auth != nil || len(record.WithheldHeaders) != 1 {
+		t.Errorf("auth = %+v, withheld %v, want the header withheld without an auth block", record.Request.Auth, record.WithheldHeaders)
+	}
+	if got := record.replayRequest().Headers["Authorization"]; got != `Digest username="alice"` {
+		t.Errorf("replayed Authorization = %q, want the original value", got)
+	}
+	if _, err := rp.SaveRecordCredentials(record.ID); err == nil {
+		t.Errorf("SaveRecordCredentials succeeded for credentials that can't be stored")
+	}
+}